@@ -0,0 +1,279 @@
+package batch
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alidadar7676/ComputerVision/imageio"
+)
+
+// Task processes one input file. base is where the outputs of the input
+// go: its path below the output directory, mirroring its place below the
+// input directory, without extension. Outputs lists the files Run writes,
+// which is how up-to-date inputs are recognized.
+type Task interface {
+	Outputs(input string, base string) []string
+	Run(input string, base string) error
+}
+
+// Options configures Run. Patterns are globs matched against file names; no
+// pattern selects every file with an image extension. Recursive descends into
+// subdirectories. At most Workers inputs are processed at once, 0 uses one
+// per CPU. Force processes inputs whose outputs are newer than them too.
+type Options struct {
+	Patterns  []string
+	Recursive bool
+	Workers   int
+	Force     bool
+}
+
+type Failure struct {
+	Input string
+	Err   error
+}
+
+// Report summarizes a run. Failures are ordered by input path.
+type Report struct {
+	Processed int
+	Skipped   int
+	Failures  []Failure
+	Duration  time.Duration
+}
+
+// Run applies task to every selected file below inputDir. A failing input
+// is recorded in the report and does not stop the others; the error is only
+// set when the run could not start.
+func Run(inputDir, outputDir string, task Task, opts Options) (*Report, error) {
+	for _, pattern := range opts.Patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q", pattern)
+		}
+	}
+	if opts.Workers < 0 {
+		return nil, errors.New("Number of workers must not be negative")
+	}
+	workers := opts.Workers
+	if workers == 0 {
+		workers = runtime.NumCPU()
+	}
+
+	start := time.Now()
+	report := &Report{}
+	inputs, err := collect(inputDir, outputDir, opts, report)
+	if err != nil {
+		return nil, err
+	}
+	inputs = unique(inputs, inputDir, outputDir, task, report)
+
+	var mutex sync.Mutex
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for input := range jobs {
+				skipped, err := process(input, inputDir, outputDir, task, opts.Force)
+				mutex.Lock()
+				switch {
+				case err != nil:
+					report.Failures = append(report.Failures, Failure{Input: input, Err: err})
+				case skipped:
+					report.Skipped++
+				default:
+					report.Processed++
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+	for _, input := range inputs {
+		jobs <- input
+	}
+	close(jobs)
+	wg.Wait()
+
+	sort.Slice(report.Failures, func(i, j int) bool {
+		return report.Failures[i].Input < report.Failures[j].Input
+	})
+	report.Duration = time.Since(start)
+	return report, nil
+}
+
+// collect lists the selected files in lexical order. The output directory is
+// skipped when it lies inside the input directory. Entries that can not be
+// read are recorded as failures of the report and skipped.
+func collect(inputDir, outputDir string, opts Options, report *Report) ([]string, error) {
+	info, err := os.Stat(inputDir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", inputDir)
+	}
+	absOutput, err := filepath.Abs(outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	err = filepath.Walk(inputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			report.Failures = append(report.Failures, Failure{Input: path, Err: err})
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			if path == inputDir {
+				return nil
+			}
+			if abs, err := filepath.Abs(path); err == nil && abs == absOutput {
+				return filepath.SkipDir
+			}
+			if !opts.Recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if selected(info.Name(), opts.Patterns) {
+			res = append(res, path)
+		}
+		return nil
+	})
+	return res, err
+}
+
+// unique drops the inputs that share an output with another input, like
+// a.png and a.jpg when the outputs get a fixed extension, and records them
+// as failures. Running them would write the same files concurrently.
+func unique(inputs []string, inputDir, outputDir string, task Task, report *Report) []string {
+	writers := map[string][]string{}
+	outputs := make([][]string, len(inputs))
+	for i, input := range inputs {
+		base, err := baseOf(input, inputDir, outputDir)
+		if err != nil {
+			continue
+		}
+		outputs[i] = task.Outputs(input, base)
+		for _, output := range outputs[i] {
+			writers[output] = append(writers[output], input)
+		}
+	}
+
+	var res []string
+	for i, input := range inputs {
+		var err error
+		for _, output := range outputs[i] {
+			if others := writers[output]; len(others) > 1 {
+				err = fmt.Errorf("%s is also the output of %s", output, strings.Join(without(others, input), ", "))
+				break
+			}
+		}
+		if err != nil {
+			report.Failures = append(report.Failures, Failure{Input: input, Err: err})
+		} else {
+			res = append(res, input)
+		}
+	}
+	return res
+}
+
+func without(list []string, s string) []string {
+	var res []string
+	for _, v := range list {
+		if v != s {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func selected(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		_, err := imageio.FormatFromPath(name)
+		return err == nil
+	}
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// process runs task on input unless its outputs are up to date. A panic of
+// the task is reported as its failure, and the outputs of a failed task are
+// removed so no partial result is mistaken for an up to date one.
+func process(input, inputDir, outputDir string, task Task, force bool) (skipped bool, err error) {
+	base, err := baseOf(input, inputDir, outputDir)
+	if err != nil {
+		return false, err
+	}
+	if !force && upToDate(input, task.Outputs(input, base)) {
+		return true, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(base), 0755); err != nil {
+		return false, err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+		if err != nil {
+			for _, output := range task.Outputs(input, base) {
+				os.Remove(output)
+			}
+		}
+	}()
+	return false, task.Run(input, base)
+}
+
+// baseOf returns the path below outputDir that mirrors input, without
+// extension.
+func baseOf(input, inputDir, outputDir string) (string, error) {
+	rel, err := filepath.Rel(inputDir, input)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(outputDir, strings.TrimSuffix(rel, filepath.Ext(rel))), nil
+}
+
+// upToDate reports whether every output exists, is not empty and is not
+// older than input. Tasks write their outputs atomically, an empty file is
+// left over from an interrupted run.
+func upToDate(input string, outputs []string) bool {
+	in, err := os.Stat(input)
+	if err != nil || len(outputs) == 0 {
+		return false
+	}
+	for _, output := range outputs {
+		out, err := os.Stat(output)
+		if err != nil || out.Size() == 0 || out.ModTime().Before(in.ModTime()) {
+			return false
+		}
+	}
+	return true
+}
+
+// Write prints the counts and one line per failure.
+func (r *Report) Write(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "processed %d, skipped %d, failed %d in %v\n", r.Processed, r.Skipped, len(r.Failures), r.Duration.Round(time.Millisecond))
+	for _, f := range r.Failures {
+		if err != nil {
+			break
+		}
+		_, err = fmt.Fprintf(w, "FAILED %s: %v\n", f.Input, f.Err)
+	}
+	return err
+}
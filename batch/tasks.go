@@ -0,0 +1,75 @@
+package batch
+
+import (
+	"errors"
+	"image"
+	"path/filepath"
+
+	"github.com/alidadar7676/ComputerVision/imageio"
+	"github.com/alidadar7676/ComputerVision/pipeline"
+)
+
+// ImageTask applies Func to every input and writes the result with the
+// extension Ext, which also selects the format.
+type ImageTask struct {
+	Ext  string
+	Func func(img image.Image) (image.Image, error)
+}
+
+func (t ImageTask) Outputs(input string, base string) []string {
+	return []string{base + t.Ext}
+}
+
+func (t ImageTask) Run(input string, base string) error {
+	img, _, err := imageio.ReadFile(input)
+	if err != nil {
+		return err
+	}
+	res, err := t.Func(img)
+	if err != nil {
+		return err
+	}
+	return imageio.WriteFile(base+t.Ext, res, imageio.DefaultEncodeOptions())
+}
+
+// RecipeTask runs a recipe with a single input on every file and saves its
+// outputs in a directory per input.
+type RecipeTask struct {
+	recipe *pipeline.Recipe
+}
+
+func NewRecipeTask(recipe *pipeline.Recipe) (*RecipeTask, error) {
+	if err := recipe.Validate(); err != nil {
+		return nil, err
+	}
+	if len(recipe.Inputs) != 1 {
+		return nil, errors.New("Batch recipes must have exactly one input")
+	}
+	if len(recipe.Outputs) == 0 {
+		return nil, errors.New("Recipe has no outputs")
+	}
+	return &RecipeTask{recipe: recipe}, nil
+}
+
+func (t *RecipeTask) Outputs(input string, base string) []string {
+	res := make([]string, 0, len(t.recipe.Outputs))
+	for _, path := range t.recipe.Outputs {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(base, path)
+		}
+		res = append(res, path)
+	}
+	return res
+}
+
+func (t *RecipeTask) Run(input string, base string) error {
+	img, _, err := imageio.ReadFile(input)
+	if err != nil {
+		return err
+	}
+	res, err := t.recipe.Run(map[string]image.Image{t.recipe.Inputs[0]: img})
+	if err != nil {
+		return err
+	}
+	return res.Save(t.recipe, base)
+}
@@ -6,32 +6,59 @@ import (
 	"math"
 
 	"github.com/alidadar7676/ComputerVision/convolution"
+	"github.com/alidadar7676/ComputerVision/floatImage"
+	"github.com/alidadar7676/ComputerVision/padding"
 	"github.com/alidadar7676/ComputerVision/utils"
 )
 
 func GaussianBlurGray(img *image.Gray, radius float64, sigma float64) (*image.Gray, error) {
-	if radius <= 0 {
-		return nil, errors.New("radius must be bigger then 0")
+	if mat, err := GaussianBlur(floatImage.FromGray(img), radius, sigma); err != nil {
+		return nil, err
+	} else {
+		return utils.CreateGrayImage(mat), nil
 	}
-	if mat, err := convolution.ConvolveGray(img, generateGaussianKernel(radius, sigma).Normalize()); err != nil {
+}
+
+func GaussianBlurGray16(img *image.Gray16, radius float64, sigma float64) (*image.Gray16, error) {
+	if mat, err := GaussianBlur(floatImage.FromGray16(img), radius, sigma); err != nil {
 		return nil, err
 	} else {
-		return utils.CreateGrayImage(mat, img.Rect), nil
+		return utils.CreateGray16Image(mat), nil
+	}
+}
+
+func GaussianBlur(img *floatImage.Gray, radius float64, sigma float64) (*floatImage.Gray, error) {
+	if radius <= 0 {
+		return nil, errors.New("radius must be bigger then 0")
 	}
+	return convolution.Convolve(img, generateGaussianKernel(radius, sigma).Normalize(), padding.BorderReplicate)
 }
 
 func generateGaussianKernel(radius float64, sigma float64) *convolution.Kernel {
 	length := int(math.Ceil(2*radius + 1))
-	kernel, _ := convolution.NewKernel(length, length)
-	for x := 0; x < length; x++ {
-		for y := 0; y < length; y++ {
-			kernel.Set(x, y, gaussianFunc(float64(x)-radius, float64(y)-radius, sigma))
-		}
+	vector := make([]float64, length)
+	for i := range vector {
+		vector[i] = gaussianFunc(float64(i)-radius, sigma)
 	}
+	kernel, _ := convolution.NewSeparableKernel(vector, vector)
 	return kernel
 }
 
-func gaussianFunc(x, y, sigma float64) float64 {
+func gaussianFunc(x, sigma float64) float64 {
 	sigSqr := sigma * sigma
-	return (1.0 / (2 * math.Pi * sigSqr)) * math.Exp(-(x*x+y*y)/(2*sigSqr))
+	return (1.0 / math.Sqrt(2*math.Pi*sigSqr)) * math.Exp(-(x*x)/(2*sigSqr))
+}
+
+func GaussianBlurColor(img image.Image, radius float64, sigma float64) (image.Image, error) {
+	if radius <= 0 {
+		return nil, errors.New("radius must be bigger then 0")
+	}
+	return convolution.ConvolveColor(img, generateGaussianKernel(radius, sigma).Normalize(), padding.BorderReplicate)
+}
+
+func GaussianBlurMulti(img *floatImage.Multi, radius float64, sigma float64) (*floatImage.Multi, error) {
+	if radius <= 0 {
+		return nil, errors.New("radius must be bigger then 0")
+	}
+	return convolution.ConvolveMulti(img, generateGaussianKernel(radius, sigma).Normalize(), padding.BorderReplicate)
 }
@@ -0,0 +1,49 @@
+package convolution
+
+import (
+	"errors"
+	"image"
+
+	"github.com/alidadar7676/ComputerVision/floatImage"
+	"github.com/alidadar7676/ComputerVision/padding"
+)
+
+// ConvolveMulti convolves every channel of img, including alpha, as it is.
+// Images with alpha should be premultiplied first, as ConvolveColor does.
+func ConvolveMulti(img *floatImage.Multi, kernel *Kernel, border padding.BorderMode) (*floatImage.Multi, error) {
+	res := floatImage.NewMulti(img.Rect, img.Channels)
+	for c := 0; c < img.Channels; c++ {
+		channel, err := Convolve(img.Channel(c), kernel, border)
+		if err != nil {
+			return nil, err
+		}
+		if err := res.SetChannel(c, channel); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// ConvolveColor convolves the alpha-premultiplied color and the alpha channel
+// of img and returns an image of the same type, so transparent pixels do not
+// bleed their color into their neighbours. YCbCr images store their chroma
+// at a lower resolution the kernel was not made for, so they are convolved
+// in RGB and returned as *image.RGBA.
+func ConvolveColor(img image.Image, kernel *Kernel, border padding.BorderMode) (image.Image, error) {
+	switch img.(type) {
+	case *image.RGBA, *image.YCbCr, *image.NRGBA:
+	default:
+		return nil, errors.New("Unsupported image type")
+	}
+	src := floatImage.FromColor(img)
+	src.Premultiply()
+	res, err := ConvolveMulti(src, kernel, border)
+	if err != nil {
+		return nil, err
+	}
+	res.Unpremultiply()
+	if _, ok := img.(*image.NRGBA); ok {
+		return res.ToNRGBA(), nil
+	}
+	return res.ToRGBA(), nil
+}
@@ -0,0 +1,46 @@
+package convolution
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/alidadar7676/ComputerVision/padding"
+)
+
+func TestConvolveColorAlpha(t *testing.T) {
+	k, _ := NewSeparableKernel([]float64{1, 1, 1}, []float64{1})
+	k = k.Normalize()
+
+	// An opaque red pixel next to a transparent green one must stay red and
+	// only lose opacity.
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 1))
+	src.SetNRGBA(0, 0, color.NRGBA{G: 255})
+	src.SetNRGBA(1, 0, color.NRGBA{R: 255, A: 255})
+	src.SetNRGBA(2, 0, color.NRGBA{G: 255})
+	res, err := ConvolveColor(src, k, padding.BorderConstant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := res.(*image.NRGBA).NRGBAAt(1, 0)
+	if got.R != 255 || got.G != 0 || got.B != 0 || got.A != 85 {
+		t.Errorf("center = %v, want {255 0 0 85}", got)
+	}
+	got = res.(*image.NRGBA).NRGBAAt(0, 0)
+	if got.R != 255 || got.G != 0 || got.A != 85 {
+		t.Errorf("neighbour = %v, want {255 0 0 85}", got)
+	}
+
+	// RGBA input is premultiplied already and must give the same colors.
+	rgba := image.NewRGBA(src.Rect)
+	for x := 0; x < 3; x++ {
+		rgba.Set(x, 0, src.At(x, 0))
+	}
+	res, err = ConvolveColor(rgba, k, padding.BorderConstant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.(*image.RGBA).RGBAAt(1, 0); got != (color.RGBA{R: 85, A: 85}) {
+		t.Errorf("RGBA center = %v, want {85 0 0 85}", got)
+	}
+}
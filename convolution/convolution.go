@@ -3,34 +3,48 @@ package convolution
 import (
 	"image"
 
+	"github.com/alidadar7676/ComputerVision/floatImage"
 	"github.com/alidadar7676/ComputerVision/padding"
-	"github.com/alidadar7676/ComputerVision/utils"
+	"github.com/alidadar7676/ComputerVision/parallel"
 )
 
-func ConvolveGray(img *image.Gray, kernel *Kernel) ([][]float64, error) {
-	originalSize := img.Bounds().Size()
-	kernelSize := kernel.Size()
+func ConvolveGray(img *image.Gray, kernel *Kernel, border padding.BorderMode) (*floatImage.Gray, error) {
+	return Convolve(floatImage.FromGray(img), kernel, border)
+}
+
+func ConvolveGray16(img *image.Gray16, kernel *Kernel, border padding.BorderMode) (*floatImage.Gray, error) {
+	return Convolve(floatImage.FromGray16(img), kernel, border)
+}
 
-	result := make([][]float64, originalSize.X)
-	for i := 0; i < originalSize.X; i++ {
-		result[i] = make([]float64, originalSize.Y)
+func Convolve(img *floatImage.Gray, kernel *Kernel, border padding.BorderMode) (*floatImage.Gray, error) {
+	kernelSize := kernel.Size()
+	if row, column, ok := kernel.Separable(); ok {
+		if len(row)+len(column) >= SeparableFFTThreshold {
+			return ConvolveFFT(img, kernel, border)
+		}
+		return ConvolveSeparable(img, row, column, border)
 	}
+	if kernelSize.X*kernelSize.Y >= FFTThreshold {
+		return ConvolveFFT(img, kernel, border)
+	}
+
+	result := floatImage.NewGray(img.Rect)
 
-	padded, err := padding.Padding(img, kernelSize)
+	padded, err := padding.PaddingFloat(img, kernelSize, border)
 	if err != nil {
 		return nil, err
 	}
 
-	utils.ForEachPixel(originalSize, func(x int, y int) {
+	origin := padded.Rect.Min.Sub(img.Rect.Min)
+	parallel.ForEachPixel(img.Rect, func(x int, y int) {
 		sum := float64(0)
 		for ky := 0; ky < kernelSize.Y; ky++ {
 			for kx := 0; kx < kernelSize.X; kx++ {
-				pixel := padded.GrayAt(x+kx, y+ky)
-				kE := kernel.At(kx, ky)
-				sum += float64(pixel.Y) * kE
+				pixel := padded.Pix[padded.PixOffset(x+origin.X+kx, y+origin.Y+ky)]
+				sum += pixel * kernel.At(kx, ky)
 			}
 		}
-		result[x][y] = sum
+		result.Pix[result.PixOffset(x, y)] = sum
 	})
 	return result, nil
 }
@@ -0,0 +1,69 @@
+package convolution
+
+import (
+	"github.com/alidadar7676/ComputerVision/fft"
+	"github.com/alidadar7676/ComputerVision/floatImage"
+	"github.com/alidadar7676/ComputerVision/padding"
+)
+
+var (
+	// FFTThreshold is the kernel area from which Convolve uses ConvolveFFT
+	// instead of the direct method.
+	FFTThreshold = 15 * 15
+	// SeparableFFTThreshold is the same limit for separable kernels, as the
+	// sum of the row and column lengths.
+	SeparableFFTThreshold = 2 * 81
+)
+
+// ConvolveFFT computes the same result as the direct convolution by
+// multiplying the spectra of the padded image and the kernel. The image is
+// padded with the requested border mode first, so the borders match.
+func ConvolveFFT(img *floatImage.Gray, kernel *Kernel, border padding.BorderMode) (*floatImage.Gray, error) {
+	kernelSize := kernel.Size()
+	padded, err := padding.PaddingFloat(img, kernelSize, border)
+	if err != nil {
+		return nil, err
+	}
+
+	size := padded.Rect.Size()
+	width, height := fft.NextPowerOfTwo(size.X), fft.NextPowerOfTwo(size.Y)
+
+	signal := make([]complex128, width*height)
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			signal[y*width+x] = complex(padded.Pix[padded.PixOffset(padded.Rect.Min.X+x, padded.Rect.Min.Y+y)], 0)
+		}
+	}
+
+	// The kernel is stored mirrored around the origin, which turns the
+	// circular convolution into the correlation the direct method computes.
+	response := make([]complex128, width*height)
+	for ky := 0; ky < kernelSize.Y; ky++ {
+		for kx := 0; kx < kernelSize.X; kx++ {
+			x := (width - kx) % width
+			y := (height - ky) % height
+			response[y*width+x] = complex(kernel.At(kx, ky), 0)
+		}
+	}
+
+	if signal, err = fft.FFT2(signal, width, height); err != nil {
+		return nil, err
+	}
+	if response, err = fft.FFT2(response, width, height); err != nil {
+		return nil, err
+	}
+	for i := range signal {
+		signal[i] *= response[i]
+	}
+	if signal, err = fft.IFFT2(signal, width, height); err != nil {
+		return nil, err
+	}
+
+	result := floatImage.NewGray(img.Rect)
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			result.Pix[result.PixOffset(x, y)] = real(signal[(y-img.Rect.Min.Y)*width+(x-img.Rect.Min.X)])
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,113 @@
+package convolution
+
+import (
+	"image"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/alidadar7676/ComputerVision/floatImage"
+	"github.com/alidadar7676/ComputerVision/padding"
+)
+
+var borders = []padding.BorderMode{
+	padding.BorderReplicate,
+	padding.BorderConstant,
+	padding.BorderReflect,
+	padding.BorderReflect101,
+	padding.BorderWrap,
+}
+
+func randomGray(r *rand.Rand, rect image.Rectangle) *floatImage.Gray {
+	img := floatImage.NewGray(rect)
+	for i := range img.Pix {
+		img.Pix[i] = 255 * r.Float64()
+	}
+	return img
+}
+
+func randomKernel(r *rand.Rand, width, height int) *Kernel {
+	k, _ := NewKernel(width, height)
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			k.Set(x, y, r.Float64()-0.5)
+		}
+	}
+	return k
+}
+
+func maxDifference(a, b *floatImage.Gray) float64 {
+	max := 0.0
+	for y := a.Rect.Min.Y; y < a.Rect.Max.Y; y++ {
+		for x := a.Rect.Min.X; x < a.Rect.Max.X; x++ {
+			max = math.Max(max, math.Abs(a.FloatAt(x, y)-b.FloatAt(x, y)))
+		}
+	}
+	return max
+}
+
+// TestConvolveFFT compares ConvolveFFT with the direct method on odd and
+// power of two image sizes, for kernels of odd and even size and kernels
+// larger than the image, in every border mode.
+func TestConvolveFFT(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	rects := []image.Rectangle{
+		image.Rect(0, 0, 16, 8),
+		image.Rect(0, 0, 17, 9),
+		image.Rect(0, 0, 31, 13),
+		image.Rect(0, 0, 3, 2),
+		image.Rect(5, -3, 24, 8),
+	}
+	kernels := []image.Point{{3, 3}, {5, 7}, {4, 6}, {9, 1}, {11, 13}}
+	for _, rect := range rects {
+		img := randomGray(r, rect)
+		for _, size := range kernels {
+			kernel := randomKernel(r, size.X, size.Y)
+			if _, _, ok := kernel.Separable(); ok && size.X > 1 && size.Y > 1 {
+				t.Fatalf("random %v kernel is separable", size)
+			}
+			for _, border := range borders {
+				direct, err := Convolve(img, kernel, border)
+				if err != nil {
+					t.Fatal(err)
+				}
+				res, err := ConvolveFFT(img, kernel, border)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if res.Rect != img.Rect {
+					t.Fatalf("%v: result bounds %v", rect, res.Rect)
+				}
+				if diff := maxDifference(direct, res); diff > 1e-9 {
+					t.Errorf("image %v, kernel %v, %v border: difference %g", rect, size, border, diff)
+				}
+			}
+		}
+	}
+}
+
+// TestConvolveFFTSeparable compares ConvolveFFT with ConvolveSeparable,
+// which is what Convolve uses for separable kernels below the threshold.
+func TestConvolveFFTSeparable(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	img := randomGray(r, image.Rect(0, 0, 21, 16))
+	row := []float64{1, 4, 6, 4, 1}
+	column := []float64{-1, 0, 1}
+	kernel, err := NewSeparableKernel(row, column)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, border := range borders {
+		separable, err := ConvolveSeparable(img, row, column, border)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := ConvolveFFT(img, kernel, border)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := maxDifference(separable, res); diff > 1e-9 {
+			t.Errorf("%v border: difference %g", border, diff)
+		}
+	}
+}
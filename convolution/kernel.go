@@ -10,34 +10,106 @@ type Kernel struct {
 	Content [][]float64
 	Width   int
 	Height  int
+
+	row    []float64
+	column []float64
 }
 
 func NewKernel(width int, height int) (*Kernel, error) {
 	if width < 0 || height < 0 {
 		return nil, errors.New("Negative kernel size")
 	}
-	m := make([][]float64, height)
+	m := make([][]float64, width)
 	for i := range m {
-		m[i] = make([]float64, width)
+		m[i] = make([]float64, height)
 	}
 	return &Kernel{Content: m, Width: width, Height: height}, nil
 }
 
+// NewSeparableKernel builds the kernel k(x, y) = row[x] * column[y] and
+// remembers the two factors so convolutions can run in two 1D passes.
+func NewSeparableKernel(row []float64, column []float64) (*Kernel, error) {
+	k, err := NewKernel(len(row), len(column))
+	if err != nil {
+		return nil, err
+	}
+	for x := range row {
+		for y := range column {
+			k.Content[x][y] = row[x] * column[y]
+		}
+	}
+	k.row = append([]float64(nil), row...)
+	k.column = append([]float64(nil), column...)
+	return k, nil
+}
+
 func (k *Kernel) At(x, y int) float64 {
 	return k.Content[x][y]
 }
 func (k *Kernel) Set(x int, y int, value float64) {
 	k.Content[x][y] = value
+	k.row, k.column = nil, nil
 }
 
 func (k *Kernel) Size() image.Point {
 	return image.Point{X: k.Width, Y: k.Height}
 }
 
+// Separable returns the row and column factors of the kernel. The factors
+// given to NewSeparableKernel are returned while they still match Content,
+// which can be written directly; other kernels are checked for being rank
+// one.
+func (k *Kernel) Separable() ([]float64, []float64, bool) {
+	if k.Width == 0 || k.Height == 0 {
+		return nil, nil, false
+	}
+
+	px, py, max := 0, 0, 0.0
+	for x := 0; x < k.Width; x++ {
+		for y := 0; y < k.Height; y++ {
+			if math.Abs(k.At(x, y)) > max {
+				px, py, max = x, y, math.Abs(k.At(x, y))
+			}
+		}
+	}
+	if max == 0 {
+		return nil, nil, false
+	}
+	if len(k.row) == k.Width && len(k.column) == k.Height && k.factors(k.row, k.column, max) {
+		return k.row, k.column, true
+	}
+
+	row := make([]float64, k.Width)
+	column := make([]float64, k.Height)
+	for x := range row {
+		row[x] = k.At(x, py)
+	}
+	for y := range column {
+		column[y] = k.At(px, y) / k.At(px, py)
+	}
+	if !k.factors(row, column, max) {
+		return nil, nil, false
+	}
+	return row, column, true
+}
+
+// factors reports whether row and column multiply to the kernel, up to a
+// tolerance relative to its largest absolute value max.
+func (k *Kernel) factors(row, column []float64, max float64) bool {
+	for x := range row {
+		for y := range column {
+			if math.Abs(row[x]*column[y]-k.At(x, y)) > 1e-9*max {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 func (k *Kernel) AbSum() float64 {
 	var sum float64
-	for x := 0; x < k.Height; x++ {
-		for y := 0; y < k.Width; y++ {
+	for x := 0; x < k.Width; x++ {
+		for y := 0; y < k.Height; y++ {
 			sum += math.Abs(k.At(x, y))
 		}
 	}
@@ -45,16 +117,35 @@ func (k *Kernel) AbSum() float64 {
 }
 
 func (k *Kernel) Normalize() *Kernel {
+	if row, column, ok := k.Separable(); ok {
+		return normalizeSeparable(row, column)
+	}
 	normalized, _ := NewKernel(k.Width, k.Height)
 	sum := k.AbSum()
 	if sum == 0 {
 		sum = 1
 
 	}
-	for x := 0; x < k.Height; x++ {
-		for y := 0; y < k.Width; y++ {
+	for x := 0; x < k.Width; x++ {
+		for y := 0; y < k.Height; y++ {
 			normalized.Set(x, y, k.At(x, y)/sum)
 		}
 	}
 	return normalized
 }
+
+func normalizeSeparable(row, column []float64) *Kernel {
+	scaled := func(v []float64) []float64 {
+		sum := 0.0
+		for _, e := range v {
+			sum += math.Abs(e)
+		}
+		res := make([]float64, len(v))
+		for i, e := range v {
+			res[i] = e / sum
+		}
+		return res
+	}
+	normalized, _ := NewSeparableKernel(scaled(row), scaled(column))
+	return normalized
+}
@@ -0,0 +1,47 @@
+package convolution
+
+import (
+	"errors"
+	"image"
+
+	"github.com/alidadar7676/ComputerVision/floatImage"
+	"github.com/alidadar7676/ComputerVision/padding"
+	"github.com/alidadar7676/ComputerVision/parallel"
+)
+
+// ConvolveSeparable convolves img with the kernel k(x, y) = row[x] * column[y]
+// using a horizontal and a vertical pass, which costs O(w + h) per pixel
+// instead of O(w * h).
+func ConvolveSeparable(img *floatImage.Gray, row []float64, column []float64, border padding.BorderMode) (*floatImage.Gray, error) {
+	if len(row) == 0 || len(column) == 0 {
+		return nil, errors.New("Empty kernel")
+	}
+	padded, err := padding.PaddingFloat(img, image.Point{len(row), len(column)}, border)
+	if err != nil {
+		return nil, err
+	}
+
+	left := img.Rect.Min.X - padded.Rect.Min.X
+	top := img.Rect.Min.Y - padded.Rect.Min.Y
+
+	horizontal := floatImage.NewGray(image.Rect(img.Rect.Min.X, padded.Rect.Min.Y, img.Rect.Max.X, padded.Rect.Max.Y))
+	parallel.ForEachPixel(horizontal.Rect, func(x int, y int) {
+		sum := float64(0)
+		offset := padded.PixOffset(x-left, y)
+		for kx, kE := range row {
+			sum += padded.Pix[offset+kx] * kE
+		}
+		horizontal.Pix[horizontal.PixOffset(x, y)] = sum
+	})
+
+	result := floatImage.NewGray(img.Rect)
+	parallel.ForEachPixel(img.Rect, func(x int, y int) {
+		sum := float64(0)
+		offset := horizontal.PixOffset(x, y-top)
+		for ky, kE := range column {
+			sum += horizontal.Pix[offset+ky*horizontal.Stride] * kE
+		}
+		result.Pix[result.PixOffset(x, y)] = sum
+	})
+	return result, nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alidadar7676/ComputerVision/batch"
+	"github.com/alidadar7676/ComputerVision/imageio"
+	"github.com/alidadar7676/ComputerVision/pipeline"
+)
+
+// batchable are the commands that map one input file to one output file.
+var batchable = []string{"blur", "sobel", "canny", "threshold", "resize", "convert", "sift"}
+
+func batchCommand(args []string) error {
+	flags := newFlagSet("batch", "input-dir output-dir [command [command flags]]",
+		"Runs a command, or a recipe with -recipe, on every image of input-dir and mirrors the\n"+
+			"directory structure in output-dir. Batchable commands: "+strings.Join(batchable, ", ")+".\n"+
+			"Example: cv batch -r -pattern '*.png' in out canny -auto otsu")
+	pattern := flags.String("pattern", "", "comma separated globs of the file names to process, default all images")
+	var opts batch.Options
+	flags.BoolVar(&opts.Recursive, "r", false, "descend into subdirectories")
+	flags.IntVar(&opts.Workers, "j", 0, "number of files processed at once, 0 uses one per CPU")
+	flags.BoolVar(&opts.Force, "force", false, "process files whose outputs are up to date too")
+	ext := flags.String("ext", "", "extension of the outputs of a command, default the input extension (.json for sift)")
+	recipePath := flags.String("recipe", "", "recipe to run instead of a command, outputs go to a directory per image")
+	reportPath := flags.String("report", "", "also write the report to this file")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() < 2 {
+		return usagef("input and output directories are required")
+	}
+	if *pattern != "" {
+		opts.Patterns = strings.Split(*pattern, ",")
+	}
+
+	var task batch.Task
+	switch {
+	case *recipePath != "" && flags.NArg() > 2:
+		return usagef("give either a command or -recipe")
+	case *recipePath != "":
+		recipe, err := pipeline.LoadRecipe(*recipePath)
+		if err != nil {
+			return err
+		}
+		if task, err = batch.NewRecipeTask(recipe); err != nil {
+			return err
+		}
+	case flags.NArg() > 2:
+		t, err := newCommandTask(flags.Arg(2), flags.Args()[3:], *ext)
+		if err != nil {
+			return err
+		}
+		task = t
+	default:
+		return usagef("a command or -recipe is required")
+	}
+
+	report, err := batch.Run(flags.Arg(0), flags.Arg(1), task, opts)
+	if err != nil {
+		return err
+	}
+	if err := report.Write(os.Stderr); err != nil {
+		return err
+	}
+	if *reportPath != "" {
+		file, err := os.Create(*reportPath)
+		if err != nil {
+			return err
+		}
+		if err := report.Write(file); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+	if len(report.Failures) > 0 {
+		return fmt.Errorf("%d of %d files failed", len(report.Failures), len(report.Failures)+report.Processed+report.Skipped)
+	}
+	return nil
+}
+
+// commandTask runs a cv command with fixed flags on every input.
+type commandTask struct {
+	run  func(args []string) error
+	args []string
+	ext  string
+}
+
+func newCommandTask(name string, args []string, ext string) (*commandTask, error) {
+	allowed := false
+	for _, b := range batchable {
+		allowed = allowed || b == name
+	}
+	if !allowed {
+		return nil, usagef("command %q can not run in batch mode", name)
+	}
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
+		if ext == "" && name == "sift" {
+			ext = ".json"
+		}
+		if ext != "" && !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		if _, err := imageio.FormatFromPath(ext); ext != "" && name != "sift" && err != nil {
+			return nil, usagef("%v", err)
+		}
+		// Check the arguments once here rather than failing on every file.
+		err := c.run(append(append([]string(nil), args...), checkPath, checkPath))
+		var usage *usageError
+		switch {
+		case err == errChecked:
+		case errors.As(err, &usage) && !usage.shown:
+			return nil, usagef("%s: %v", name, usage.err)
+		case err != nil:
+			return nil, err
+		default:
+			return nil, fmt.Errorf("%s did not stop at the argument check", name)
+		}
+		return &commandTask{run: c.run, args: args, ext: ext}, nil
+	}
+	return nil, usagef("unknown command %q", name)
+}
+
+func (t *commandTask) output(input, base string) string {
+	if t.ext == "" {
+		return base + filepath.Ext(input)
+	}
+	return base + t.ext
+}
+
+func (t *commandTask) Outputs(input string, base string) []string {
+	return []string{t.output(input, base)}
+}
+
+func (t *commandTask) Run(input string, base string) error {
+	args := append(append([]string(nil), t.args...), input, t.output(input, base))
+	return t.run(args)
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"github.com/alidadar7676/ComputerVision/geometry"
+	"github.com/alidadar7676/ComputerVision/matching"
+	"github.com/alidadar7676/ComputerVision/sift"
+)
+
+// addSiftFlags exposes the SIFT options, shared by every command that
+// detects keypoints.
+func addSiftFlags(flags *flag.FlagSet, opts *sift.SiftOptions) {
+	flags.IntVar(&opts.Octaves, "octaves", opts.Octaves, "number of octaves, 0 uses as many as the image size allows")
+	flags.IntVar(&opts.Layers, "layers", opts.Layers, "scales sampled per octave")
+	flags.Float64Var(&opts.Sigma, "sigma", opts.Sigma, "blur of the first scale")
+	flags.Float64Var(&opts.ContrastThreshold, "contrast", opts.ContrastThreshold, "contrast threshold of the extrema")
+	flags.Float64Var(&opts.EdgeThreshold, "edge", opts.EdgeThreshold, "principal curvature ratio threshold of the extrema")
+	flags.BoolVar(&opts.Upsample, "upsample", opts.Upsample, "double the image before the first octave")
+	flags.IntVar(&opts.MaxFeatures, "features", opts.MaxFeatures, "keep the strongest keypoints only, 0 keeps all")
+	flags.BoolVar(&opts.RootSIFT, "rootsift", opts.RootSIFT, "compute RootSIFT descriptors")
+}
+
+type keyPointOutput struct {
+	X          float64   `json:"x"`
+	Y          float64   `json:"y"`
+	Size       float64   `json:"size"`
+	Angle      float64   `json:"angle"`
+	Response   float64   `json:"response"`
+	Octave     int       `json:"octave"`
+	Descriptor []float64 `json:"descriptor,omitempty"`
+}
+
+func siftCommand(args []string) error {
+	opts := sift.DefaultSiftOptions()
+	flags := newFlagSet("sift", "[input] [output]", "Detects SIFT keypoints and writes them as JSON, or as lines of x y size angle response with -text.")
+	addSiftFlags(flags, &opts)
+	descriptors := flags.Bool("descriptors", false, "include the descriptors in the JSON output")
+	text := flags.Bool("text", false, "write one keypoint per line instead of JSON")
+	files, err := parse(flags, args, 2)
+	if err != nil {
+		return err
+	}
+	if err := opts.Validate(); err != nil {
+		return usagef("%v", err)
+	}
+
+	img, err := readImage(files[0])
+	if err != nil {
+		return err
+	}
+	keys, err := sift.SiftFeatures(grayImage(img), opts)
+	if err != nil {
+		return err
+	}
+
+	w, err := createOutput(files[1])
+	if err != nil {
+		return err
+	}
+	if *text {
+		for _, k := range keys {
+			fmt.Fprintf(w, "%.3f %.3f %.3f %.2f %.5f\n", k.X, k.Y, k.Size, k.Angle, k.Response)
+		}
+		return w.Close()
+	}
+	res := make([]keyPointOutput, len(keys))
+	for i, k := range keys {
+		res[i] = keyPointOutput{X: k.X, Y: k.Y, Size: k.Size, Angle: k.Angle, Response: k.Response, Octave: k.Octave}
+		if *descriptors {
+			res[i].Descriptor = k.Feature
+		}
+	}
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		w.Discard()
+		return err
+	}
+	return w.Close()
+}
+
+type matchOutput struct {
+	Query    int     `json:"query"`
+	Train    int     `json:"train"`
+	Distance float64 `json:"distance"`
+	QueryX   float64 `json:"query_x"`
+	QueryY   float64 `json:"query_y"`
+	TrainX   float64 `json:"train_x"`
+	TrainY   float64 `json:"train_y"`
+}
+
+type matchResult struct {
+	Matches   []matchOutput    `json:"matches"`
+	Transform *geometry.Matrix `json:"transform,omitempty"`
+}
+
+func matchCommand(args []string) error {
+	siftOptions := sift.DefaultSiftOptions()
+	opts := matching.DefaultOptions()
+	flags := newFlagSet("match", "query train [output]", "Matches the SIFT keypoints of two images and writes the matches as JSON. With -model only the inliers of the estimated transform are kept.")
+	addSiftFlags(flags, &siftOptions)
+	flags.Float64Var(&opts.Ratio, "ratio", opts.Ratio, "Lowe's ratio test, 0 disables it")
+	flags.BoolVar(&opts.CrossCheck, "cross-check", opts.CrossCheck, "keep mutual nearest neighbours only")
+	index := flags.Bool("index", false, "use an approximate kd-forest instead of brute force")
+	checks := flags.Int("checks", 64, "distance computations per query of the kd-forest, 0 searches exactly")
+	model := flags.String("model", "none", "geometric verification: none, homography, affine or similarity")
+	ransac := geometry.DefaultRansacOptions()
+	flags.Float64Var(&ransac.Threshold, "threshold", ransac.Threshold, "RANSAC reprojection threshold in pixels")
+	flags.Int64Var(&ransac.Seed, "seed", ransac.Seed, "seed of the RANSAC sampling")
+	files, err := parse(flags, args, 3)
+	if err != nil {
+		return err
+	}
+	if files[0] == "-" || files[1] == "-" {
+		return usagef("query and train images are required")
+	}
+	switch *model {
+	case "none":
+	case "homography":
+		ransac.Model = geometry.ModelHomography
+	case "affine":
+		ransac.Model = geometry.ModelAffine
+	case "similarity":
+		ransac.Model = geometry.ModelSimilarity
+	default:
+		return usagef("unknown model %q", *model)
+	}
+
+	var keys [2][]sift.KeyPoint
+	for i := range keys {
+		img, err := readImage(files[i])
+		if err != nil {
+			return err
+		}
+		if keys[i], err = sift.SiftFeatures(grayImage(img), siftOptions); err != nil {
+			return err
+		}
+	}
+	query, train := keys[0], keys[1]
+
+	var matches []matching.Match
+	if *index {
+		var idx *matching.Index
+		if idx, err = matching.NewIndex(train, matching.DefaultIndexOptions()); err != nil {
+			return err
+		}
+		matches, err = idx.Match(query, opts, *checks)
+	} else {
+		matches, err = matching.BruteForceMatch(query, train, opts)
+	}
+	if err != nil {
+		return err
+	}
+
+	var res matchResult
+	if *model != "none" {
+		src, dst := geometry.MatchedPoints(query, train, matches)
+		estimate, err := geometry.Estimate(src, dst, ransac)
+		if err != nil {
+			return err
+		}
+		inliers := matches[:0]
+		for i, m := range matches {
+			if estimate.Inliers[i] {
+				inliers = append(inliers, m)
+			}
+		}
+		matches = inliers
+		res.Transform = &estimate.Transform
+	}
+	res.Matches = make([]matchOutput, len(matches))
+	for i, m := range matches {
+		res.Matches[i] = matchOutput{
+			Query: m.QueryIndex, Train: m.TrainIndex, Distance: m.Distance,
+			QueryX: query[m.QueryIndex].X, QueryY: query[m.QueryIndex].Y,
+			TrainX: train[m.TrainIndex].X, TrainY: train[m.TrainIndex].Y,
+		}
+	}
+
+	w, err := createOutput(files[2])
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		w.Discard()
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"image"
+	"math"
+
+	"github.com/alidadar7676/ComputerVision/blurring"
+	"github.com/alidadar7676/ComputerVision/edgeDetection"
+	"github.com/alidadar7676/ComputerVision/utils"
+)
+
+func blurCommand(args []string) error {
+	flags := newFlagSet("blur", "[input] [output]", "Blurs the image with a Gaussian. Gray and 16 bit gray images keep their type.")
+	sigma := flags.Float64("sigma", 1, "standard deviation of the Gaussian")
+	radius := flags.Float64("radius", 0, "radius of the kernel, 0 uses 3 * sigma")
+	out := addOutputFlags(flags)
+	files, err := parse(flags, args, 2)
+	if err != nil {
+		return err
+	}
+	if *sigma <= 0 {
+		return usagef("sigma must be bigger then 0")
+	}
+	if *radius < 0 {
+		return usagef("radius must not be negative")
+	}
+	if *radius == 0 {
+		*radius = math.Ceil(3 * *sigma)
+	}
+
+	img, err := readImage(files[0])
+	if err != nil {
+		return err
+	}
+	res, err := blur(img, *radius, *sigma)
+	if err != nil {
+		return err
+	}
+	return out.writeImage(files[1], res)
+}
+
+func blur(img image.Image, radius, sigma float64) (image.Image, error) {
+	switch src := img.(type) {
+	case *image.Gray:
+		return blurring.GaussianBlurGray(src, radius, sigma)
+	case *image.Gray16:
+		return blurring.GaussianBlurGray16(src, radius, sigma)
+	}
+	return blurring.GaussianBlurColor(colorImage(img), radius, sigma)
+}
+
+func sobelCommand(args []string) error {
+	flags := newFlagSet("sobel", "[input] [output]", "Writes the Sobel gradient magnitude. 16 bit gray images give 16 bit results.")
+	color := flags.Bool("color", false, "use the Di Zenzo gradient of the color channels")
+	out := addOutputFlags(flags)
+	files, err := parse(flags, args, 2)
+	if err != nil {
+		return err
+	}
+
+	img, err := readImage(files[0])
+	if err != nil {
+		return err
+	}
+	var res image.Image
+	switch src := img.(type) {
+	case *image.Gray16:
+		res, err = edgeDetection.SobelGray16(src)
+	default:
+		if *color {
+			res, err = edgeDetection.SobelColor(colorImage(img))
+		} else {
+			res, err = edgeDetection.SobelGray(grayImage(img))
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return out.writeImage(files[1], res)
+}
+
+func cannyCommand(args []string) error {
+	opts := edgeDetection.DefaultCannyOptions()
+	flags := newFlagSet("canny", "[input] [output]", "Detects edges with Canny. Thresholds are in the units of the input, 0-255 or 0-65535.")
+	flags.Float64Var(&opts.Sigma, "sigma", opts.Sigma, "standard deviation of the Gaussian pre-blur, 0 disables it")
+	flags.Float64Var(&opts.Low, "low", opts.Low, "low hysteresis threshold")
+	flags.Float64Var(&opts.High, "high", opts.High, "high hysteresis threshold")
+	l1 := flags.Bool("l1", false, "use |dx| + |dy| instead of the Euclidean gradient magnitude")
+	flags.IntVar(&opts.ApertureSize, "aperture", opts.ApertureSize, "Sobel aperture: 3, 5 or 7")
+	auto := flags.String("auto", "manual", "threshold strategy: manual, median or otsu")
+	flags.Float64Var(&opts.MedianSpread, "spread", opts.MedianSpread, "relative spread of the thresholds around the median")
+	color := flags.Bool("color", false, "use the Di Zenzo gradient of the color channels")
+	out := addOutputFlags(flags)
+	files, err := parse(flags, args, 2)
+	if err != nil {
+		return err
+	}
+	opts.L2Gradient = !*l1
+	switch *auto {
+	case "manual":
+		opts.Auto = edgeDetection.ThresholdManual
+	case "median":
+		opts.Auto = edgeDetection.ThresholdMedian
+	case "otsu":
+		opts.Auto = edgeDetection.ThresholdOtsu
+	default:
+		return usagef("unknown threshold strategy %q", *auto)
+	}
+	if err := opts.Validate(); err != nil {
+		return usagef("%v", err)
+	}
+
+	img, err := readImage(files[0])
+	if err != nil {
+		return err
+	}
+	var res *image.Gray
+	switch src := img.(type) {
+	case *image.Gray16:
+		res, err = edgeDetection.CannyGray16(src, opts)
+	default:
+		if *color {
+			res, err = edgeDetection.CannyColor(colorImage(img), opts)
+		} else {
+			res, err = edgeDetection.CannyGray(grayImage(img), opts)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return out.writeImage(files[1], res)
+}
+
+func thresholdCommand(args []string) error {
+	flags := newFlagSet("threshold", "[input] [output]", "Writes white where the gray value is at least the threshold and black elsewhere.")
+	value := flags.Float64("value", 128, "threshold in the units of the input, 0-255 or 0-65535")
+	otsu := flags.Bool("otsu", false, "choose the threshold with Otsu's method")
+	invert := flags.Bool("invert", false, "swap black and white")
+	out := addOutputFlags(flags)
+	files, err := parse(flags, args, 2)
+	if err != nil {
+		return err
+	}
+
+	img, err := readImage(files[0])
+	if err != nil {
+		return err
+	}
+	res := utils.Threshold(img, *value, *otsu, *invert)
+	return out.writeImage(files[1], res)
+}
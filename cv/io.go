@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/alidadar7676/ComputerVision/imageio"
+)
+
+// checkPath is the input given to a command to check its arguments without
+// running it: every command validates its flags before reading the input,
+// where readImage stops it with errChecked. File names can not hold a NUL
+// byte, so checkPath never names a real file.
+const checkPath = "\x00check"
+
+var errChecked = errors.New("arguments checked")
+
+func readImage(path string) (image.Image, error) {
+	if path == checkPath {
+		return nil, errChecked
+	}
+	if path == "-" {
+		img, _, err := imageio.Decode(os.Stdin)
+		return img, err
+	}
+	img, _, err := imageio.ReadFile(path)
+	return img, err
+}
+
+// outputFlags are the encoding flags shared by the commands writing images.
+// The values are checked while parsing, so bad flags are usage errors found
+// before any work is done.
+type outputFlags struct {
+	format  formatFlag
+	quality qualityFlag
+	plain   bool
+}
+
+func addOutputFlags(flags *flag.FlagSet) *outputFlags {
+	o := &outputFlags{quality: qualityFlag(imageio.DefaultEncodeOptions().Quality)}
+	flags.Var(&o.format, "format", "output `format` (jpeg, png, gif, bmp, tiff, pbm, pgm, ppm); default from the output extension, png for stdout")
+	flags.Var(&o.quality, "quality", "JPEG quality `n` from 1 to 100")
+	flags.BoolVar(&o.plain, "plain", false, "write the ASCII variants of the Netpbm formats")
+	return o
+}
+
+type formatFlag imageio.Format
+
+func (f *formatFlag) String() string {
+	if f == nil || imageio.Format(*f) == imageio.FormatUnknown {
+		return ""
+	}
+	return imageio.Format(*f).String()
+}
+
+func (f *formatFlag) Set(value string) error {
+	format, err := imageio.FormatFromPath("." + value)
+	if err != nil {
+		return fmt.Errorf("unknown format %q", value)
+	}
+	*f = formatFlag(format)
+	return nil
+}
+
+type qualityFlag int
+
+func (q *qualityFlag) String() string {
+	if q == nil {
+		return ""
+	}
+	return strconv.Itoa(int(*q))
+}
+
+func (q *qualityFlag) Set(value string) error {
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+	if v < 1 || v > 100 {
+		return errors.New("JPEG quality must be between 1 and 100")
+	}
+	*q = qualityFlag(v)
+	return nil
+}
+
+func (o *outputFlags) writeImage(path string, img image.Image) error {
+	format := imageio.Format(o.format)
+	if format == imageio.FormatUnknown {
+		if path == "-" {
+			format = imageio.FormatPNG
+		} else {
+			var err error
+			if format, err = imageio.FormatFromPath(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	w, err := createOutput(path)
+	if err != nil {
+		return err
+	}
+	if err := imageio.Encode(w, img, format, imageio.EncodeOptions{Quality: int(o.quality), Plain: o.plain}); err != nil {
+		w.Discard()
+		return err
+	}
+	return w.Close()
+}
+
+// output is where a command writes its result. Close completes the output;
+// Discard drops it after a failure, so files are either written completely
+// or left as they were.
+type output interface {
+	io.Writer
+	Close() error
+	Discard()
+}
+
+// createOutput opens path for writing, or stdout for "-". Files are written
+// through an imageio.AtomicFile.
+func createOutput(path string) (output, error) {
+	if path == "-" {
+		return stdoutOutput{bufio.NewWriter(os.Stdout)}, nil
+	}
+	file, err := imageio.CreateAtomic(path)
+	if err != nil {
+		return nil, err
+	}
+	return fileOutput{file}, nil
+}
+
+type stdoutOutput struct {
+	*bufio.Writer
+}
+
+func (s stdoutOutput) Close() error {
+	return s.Flush()
+}
+
+func (s stdoutOutput) Discard() {}
+
+type fileOutput struct {
+	*imageio.AtomicFile
+}
+
+func (f fileOutput) Close() error {
+	return f.Commit()
+}
+
+// colorImage returns img as a type the color algorithms accept.
+func colorImage(img image.Image) image.Image {
+	switch img.(type) {
+	case *image.RGBA, *image.NRGBA, *image.YCbCr:
+		return img
+	}
+	res := image.NewNRGBA(img.Bounds())
+	draw.Draw(res, res.Rect, img, img.Bounds().Min, draw.Src)
+	return res
+}
+
+// grayImage returns img as 8 bit gray, converting colors by luminance.
+func grayImage(img image.Image) *image.Gray {
+	if gray, ok := img.(*image.Gray); ok {
+		return gray
+	}
+	res := image.NewGray(img.Bounds())
+	draw.Draw(res, res.Rect, img, img.Bounds().Min, draw.Src)
+	return res
+}
@@ -0,0 +1,155 @@
+// Command cv runs the algorithms of the library on image files.
+//
+//	cv <command> [flags] [input] [output]
+//
+// Inputs and outputs default to "-", which reads from stdin and writes to
+// stdout, so commands can be piped. cv exits with 0 on success, 1 when the
+// command fails and 2 on invalid usage.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"blur", "Gaussian blur", blurCommand},
+		{"sobel", "Sobel gradient magnitude", sobelCommand},
+		{"canny", "Canny edge detection", cannyCommand},
+		{"threshold", "binary threshold, fixed or Otsu", thresholdCommand},
+		{"resize", "resize with a choice of interpolation", resizeCommand},
+		{"convert", "convert between image formats", convertCommand},
+		{"sift", "detect SIFT keypoints", siftCommand},
+		{"match", "match SIFT keypoints of two images", matchCommand},
+		{"stitch", "stitch overlapping images into a panorama", stitchCommand},
+		{"run", "run a JSON or YAML recipe of operations", runCommand},
+		{"batch", "run a command or recipe on a directory of images", batchCommand},
+	}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	if len(args) == 0 {
+		printUsage(os.Stderr)
+		return exitUsage
+	}
+	name := args[0]
+	switch name {
+	case "-h", "-help", "--help", "help":
+		if len(args) > 1 {
+			return run([]string{args[1], "-h"})
+		}
+		printUsage(os.Stdout)
+		return exitOK
+	}
+
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
+		err := c.run(args[1:])
+		var usage *usageError
+		switch {
+		case err == nil, errors.Is(err, flag.ErrHelp):
+			return exitOK
+		case errors.As(err, &usage):
+			if !usage.shown {
+				fmt.Fprintf(os.Stderr, "cv %s: %v\nRun 'cv %s -h' for usage.\n", name, err, name)
+			}
+			return exitUsage
+		default:
+			fmt.Fprintf(os.Stderr, "cv %s: %v\n", name, err)
+			return exitFailure
+		}
+	}
+	fmt.Fprintf(os.Stderr, "cv: unknown command %q\n", name)
+	printUsage(os.Stderr)
+	return exitUsage
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: cv <command> [flags] [input] [output]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Inputs and outputs default to - for stdin and stdout.")
+	fmt.Fprintln(w, "Run 'cv <command> -h' for the flags of a command.")
+}
+
+// usageError marks errors caused by the command line rather than the data.
+// shown is set when the flag package already printed the problem.
+type usageError struct {
+	err   error
+	shown bool
+}
+
+func (e *usageError) Error() string {
+	return e.err.Error()
+}
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{err: fmt.Errorf(format, args...)}
+}
+
+// newFlagSet creates the flag set of a command. args describes its
+// positional arguments in the usage line.
+func newFlagSet(name, args, description string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		out := flags.Output()
+		fmt.Fprintf(out, "Usage: cv %s [flags] %s\n\n%s\n\nFlags:\n", name, args, description)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parse parses the flags and returns exactly n positional arguments,
+// filling the missing ones with "-".
+func parse(flags *flag.FlagSet, args []string, n int) ([]string, error) {
+	if err := parseFlags(flags, args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > n {
+		return nil, usagef("too many arguments")
+	}
+	res := append([]string(nil), flags.Args()...)
+	for len(res) < n {
+		res = append(res, "-")
+	}
+	return res, nil
+}
+
+// parseFlags is flags.Parse with the errors run understands.
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return &usageError{err: err, shown: true}
+	}
+	return nil
+}
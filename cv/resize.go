@@ -0,0 +1,113 @@
+package main
+
+import (
+	"image"
+	"math"
+
+	"github.com/alidadar7676/ComputerVision/floatImage"
+	"github.com/alidadar7676/ComputerVision/geometry"
+	"github.com/alidadar7676/ComputerVision/padding"
+	"github.com/alidadar7676/ComputerVision/warping"
+)
+
+func resizeCommand(args []string) error {
+	flags := newFlagSet("resize", "[input] [output]", "Resizes the image. Give -scale, or -width and/or -height; a missing side keeps the aspect ratio.")
+	width := flags.Int("width", 0, "output width in pixels")
+	height := flags.Int("height", 0, "output height in pixels")
+	scale := flags.Float64("scale", 0, "scale factor for both sides")
+	interpolation := flags.String("interp", warping.InterpolationBilinear.String(), "interpolation: nearest, bilinear, bicubic or lanczos")
+	antialias := flags.Bool("antialias", true, "blur before shrinking to avoid aliasing")
+	out := addOutputFlags(flags)
+	files, err := parse(flags, args, 2)
+	if err != nil {
+		return err
+	}
+	opts := warping.WarpOptions{Border: padding.BorderReplicate}
+	if opts.Interpolation, err = parseInterpolation(*interpolation); err != nil {
+		return err
+	}
+	if *scale < 0 || *width < 0 || *height < 0 {
+		return usagef("sizes must not be negative")
+	}
+	if *scale == 0 && *width == 0 && *height == 0 {
+		return usagef("give -scale, -width or -height")
+	}
+
+	img, err := readImage(files[0])
+	if err != nil {
+		return err
+	}
+	size := img.Bounds().Size()
+	w, h := *width, *height
+	switch {
+	case *scale > 0:
+		w = int(math.Round(float64(size.X) * *scale))
+		h = int(math.Round(float64(size.Y) * *scale))
+	case w == 0:
+		w = int(math.Round(float64(size.X) * float64(h) / float64(size.Y)))
+	case h == 0:
+		h = int(math.Round(float64(size.Y) * float64(w) / float64(size.X)))
+	}
+	if w < 1 || h < 1 {
+		return usagef("output would be empty")
+	}
+
+	sx := float64(w) / float64(size.X)
+	sy := float64(h) / float64(size.Y)
+	if *antialias && math.Min(sx, sy) < 1 {
+		// Remove the frequencies the smaller grid can not represent.
+		sigma := (1/math.Min(sx, sy) - 1) / 2
+		if img, err = blur(img, math.Ceil(3*sigma), sigma); err != nil {
+			return err
+		}
+	}
+
+	// Pixel centers map onto pixel centers, so the image edges stay aligned.
+	min := img.Bounds().Min
+	m := geometry.Matrix{
+		sx, 0, sx*(0.5-float64(min.X)) - 0.5,
+		0, sy, sy*(0.5-float64(min.Y)) - 0.5,
+		0, 0, 1,
+	}
+	opts.Bounds = image.Rect(0, 0, w, h)
+
+	var res image.Image
+	switch src := img.(type) {
+	case *image.Gray:
+		res, err = warping.WarpAffineGray(src, m, opts)
+	case *image.Gray16:
+		var warped *floatImage.Gray
+		if warped, err = warping.WarpAffine(floatImage.FromGray16(src), m, opts); err == nil {
+			res = warped.ToGray16()
+		}
+	default:
+		res, err = warping.WarpAffineColor(img, m, opts)
+	}
+	if err != nil {
+		return err
+	}
+	return out.writeImage(files[1], res)
+}
+
+func convertCommand(args []string) error {
+	flags := newFlagSet("convert", "[input] [output]", "Converts the image to the output format.")
+	out := addOutputFlags(flags)
+	files, err := parse(flags, args, 2)
+	if err != nil {
+		return err
+	}
+	img, err := readImage(files[0])
+	if err != nil {
+		return err
+	}
+	return out.writeImage(files[1], img)
+}
+
+func parseInterpolation(name string) (warping.Interpolation, error) {
+	for i := warping.InterpolationNearest; i <= warping.InterpolationLanczos; i++ {
+		if i.String() == name {
+			return i, nil
+		}
+	}
+	return 0, usagef("unknown interpolation %q", name)
+}
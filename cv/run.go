@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"os"
+
+	"github.com/alidadar7676/ComputerVision/pipeline"
+)
+
+func runCommand(args []string) error {
+	flags := newFlagSet("run", "recipe [input ...]", "Runs a JSON or YAML recipe. The inputs are bound to the inputs of the recipe in order and the outputs are written below -o.")
+	dir := flags.String("o", ".", "output directory")
+	timings := flags.Bool("timings", false, "print the time of every step to stderr")
+	check := flags.Bool("check", false, "only validate the recipe")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() < 1 {
+		return usagef("recipe is required")
+	}
+
+	recipe, err := pipeline.LoadRecipe(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *check {
+		return nil
+	}
+	if flags.NArg()-1 != len(recipe.Inputs) {
+		return usagef("recipe needs %d inputs, got %d", len(recipe.Inputs), flags.NArg()-1)
+	}
+
+	inputs := make(map[string]image.Image)
+	for i, name := range recipe.Inputs {
+		img, err := readImage(flags.Arg(i + 1))
+		if err != nil {
+			return err
+		}
+		inputs[name] = img
+	}
+	res, err := recipe.Run(inputs)
+	if err != nil {
+		return err
+	}
+	if *timings {
+		for _, t := range res.Timings {
+			fmt.Fprintf(os.Stderr, "%-20s %v\n", t.Step, t.Duration)
+		}
+	}
+	return res.Save(recipe, *dir)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"image"
+
+	"github.com/alidadar7676/ComputerVision/stitching"
+)
+
+func stitchCommand(args []string) error {
+	opts := stitching.DefaultStitchOptions()
+	flags := newFlagSet("stitch", "input1 input2 ...", "Stitches overlapping images into a panorama written to -o.")
+	output := flags.String("o", "-", "output image")
+	blend := flags.String("blend", opts.Blend.String(), "seam blending: feather or multiband")
+	flags.IntVar(&opts.Bands, "bands", opts.Bands, "number of pyramid levels of multiband blending")
+	flags.IntVar(&opts.Reference, "reference", opts.Reference, "index of the reference image, -1 picks the best connected one")
+	flags.IntVar(&opts.MinInliers, "min-inliers", opts.MinInliers, "inliers needed to consider two images overlapping")
+	flags.Float64Var(&opts.Ransac.Threshold, "threshold", opts.Ransac.Threshold, "RANSAC reprojection threshold in pixels")
+	addSiftFlags(flags, &opts.Sift)
+	out := addOutputFlags(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	switch *blend {
+	case "feather":
+		opts.Blend = stitching.BlendFeather
+	case "multiband":
+		opts.Blend = stitching.BlendMultiBand
+	default:
+		return usagef("unknown blend %q", *blend)
+	}
+	if flags.NArg() < 2 {
+		return usagef("at least two input images are needed")
+	}
+
+	images := make([]image.Image, flags.NArg())
+	for i, path := range flags.Args() {
+		img, err := readImage(path)
+		if err != nil {
+			return err
+		}
+		images[i] = img
+	}
+
+	panorama, err := stitching.Stitch(images, opts)
+	if err != nil {
+		return err
+	}
+	return out.writeImage(*output, panorama)
+}
@@ -1,75 +1,218 @@
 package edgeDetection
 
 import (
+	"errors"
 	"image"
 	"image/color"
+	"math"
 
 	"github.com/alidadar7676/ComputerVision/blurring"
+	"github.com/alidadar7676/ComputerVision/floatImage"
 	"github.com/alidadar7676/ComputerVision/gradient"
+	"github.com/alidadar7676/ComputerVision/parallel"
 	"github.com/alidadar7676/ComputerVision/utils"
 )
 
-func CannyGray(img *image.Gray, kernelSize uint) (*image.Gray, error) {
-	blurred, err := blurring.GaussianBlurGray(img, float64(kernelSize), 1)
-	if err != nil {
-		return nil, err
+type AutoThreshold int
+
+// With an automatic strategy Low and High are ignored and derived from the
+// magnitudes of the pixels that survive non-maximum suppression. ThresholdMedian
+// uses (1 -+ MedianSpread) times their median, ThresholdOtsu uses their Otsu
+// threshold as High and half of it as Low.
+const (
+	ThresholdManual AutoThreshold = iota
+	ThresholdMedian
+	ThresholdOtsu
+)
+
+// CannyOptions configures CannyGray and CannyColor. Sigma is the standard
+// deviation of the Gaussian pre-blur (0 disables it), Low and High are the
+// hysteresis thresholds on the gradient magnitude, L2Gradient selects the
+// Euclidean magnitude instead of |dx| + |dy|, and ApertureSize is the Sobel
+// aperture (3, 5 or 7). The magnitude is in the intensity units of the input,
+// so CannyGray16 needs thresholds 257 times larger for the same edges.
+type CannyOptions struct {
+	Sigma        float64
+	Low          float64
+	High         float64
+	L2Gradient   bool
+	ApertureSize int
+	Auto         AutoThreshold
+	MedianSpread float64
+}
+
+type Thresholds struct {
+	Low  float64
+	High float64
+}
+
+func DefaultCannyOptions() CannyOptions {
+	return CannyOptions{Sigma: 1, Low: 50, High: 100, L2Gradient: true, ApertureSize: 3, MedianSpread: 0.33}
+}
+
+// Validate reports the first parameter out of range, the check the Canny
+// functions run before filtering.
+func (o CannyOptions) Validate() error {
+	if o.Sigma < 0 {
+		return errors.New("Sigma must not be negative")
+	}
+	switch o.Auto {
+	case ThresholdManual:
+		if o.Low < 0 || o.High < 0 {
+			return errors.New("Thresholds must not be negative")
+		}
+		if o.Low > o.High {
+			return errors.New("Low threshold is bigger then the high threshold")
+		}
+	case ThresholdMedian:
+		if o.MedianSpread < 0 || o.MedianSpread > 1 {
+			return errors.New("Median spread must be between 0 and 1")
+		}
+	case ThresholdOtsu:
+	default:
+		return errors.New("Unknown threshold strategy")
+	}
+	if o.ApertureSize != 3 && o.ApertureSize != 5 && o.ApertureSize != 7 {
+		return errors.New("Aperture size must be 3, 5 or 7")
 	}
+	return nil
+}
+
+func CannyGray(img *image.Gray, opts CannyOptions) (*image.Gray, error) {
+	edges, _, err := CannyGrayThresholds(img, opts)
+	return edges, err
+}
+
+// CannyGrayThresholds is CannyGray that also returns the thresholds it used,
+// which is where the automatic strategies report their choice.
+func CannyGrayThresholds(img *image.Gray, opts CannyOptions) (*image.Gray, Thresholds, error) {
+	return cannyGray(floatImage.FromGray(img), opts)
+}
+
+// CannyGray16 runs Canny on a 16 bit image without reducing it to 8 bits
+// first, so faint edges in high dynamic range data survive.
+func CannyGray16(img *image.Gray16, opts CannyOptions) (*image.Gray, error) {
+	edges, _, err := CannyGray16Thresholds(img, opts)
+	return edges, err
+}
 
-	vertical, err := gradient.Vertical(blurred)
+func CannyGray16Thresholds(img *image.Gray16, opts CannyOptions) (*image.Gray, Thresholds, error) {
+	return cannyGray(floatImage.FromGray16(img), opts)
+}
+
+func cannyGray(src *floatImage.Gray, opts CannyOptions) (*image.Gray, Thresholds, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, Thresholds{}, err
+	}
+	blurred, err := cannyBlur(src, opts.Sigma)
 	if err != nil {
-		return nil, err
+		return nil, Thresholds{}, err
 	}
-	horizontal, err := gradient.Horizontal(blurred)
+
+	vertical, horizontal, err := gradient.Sobel(blurred, opts.ApertureSize)
 	if err != nil {
-		return nil, err
+		return nil, Thresholds{}, err
 	}
 
-	g, t := gradient.GradientAndOrientation(img.Bounds().Size(), vertical, horizontal)
-	theta := discreteOrientations(img.Bounds().Size(), t)
+	g, t := gradient.GradientAndOrientation(vertical, horizontal)
+	if !opts.L2Gradient {
+		g = gradient.L1Magnitude(vertical, horizontal)
+	}
+	edges, thresholds := cannyEdges(g, t, opts)
+	return edges, thresholds, nil
+}
+
+// CannyColor runs Canny on the Di Zenzo color gradient. The color gradient is
+// always Euclidean, so L2Gradient is ignored.
+func CannyColor(img image.Image, opts CannyOptions) (*image.Gray, error) {
+	edges, _, err := CannyColorThresholds(img, opts)
+	return edges, err
+}
 
-	blurredImage := image.NewGray(blurred.Rect)
-	for i := 0; i < blurred.Bounds().Size().X; i++ {
-		for j := 0; j < blurred.Bounds().Size().Y; j++ {
-			blurredImage.SetGray(i, j, color.Gray{Y: uint8(utils.Clamp(g[i][j], utils.MinUint8, float64(utils.MaxUint8)))})
+func CannyColorThresholds(img image.Image, opts CannyOptions) (*image.Gray, Thresholds, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, Thresholds{}, err
+	}
+	src := floatImage.FromColor(img)
+	if opts.Sigma > 0 {
+		blurred, err := blurring.GaussianBlurMulti(src, blurRadius(opts.Sigma), opts.Sigma)
+		if err != nil {
+			return nil, Thresholds{}, err
 		}
+		src = blurred
 	}
 
-	thinEdges := nonMaxSuppression(blurred, g, theta)
+	g, t, err := gradient.DiZenzo(src, opts.ApertureSize)
+	if err != nil {
+		return nil, Thresholds{}, err
+	}
+	edges, thresholds := cannyEdges(g, t, opts)
+	return edges, thresholds, nil
+}
 
-	hist := doubleThreshold(thinEdges, g, 100)
-	return hist, nil
+func cannyBlur(img *floatImage.Gray, sigma float64) (*floatImage.Gray, error) {
+	if sigma == 0 {
+		return img, nil
+	}
+	return blurring.GaussianBlur(img, blurRadius(sigma), sigma)
+}
 
+func blurRadius(sigma float64) float64 {
+	return math.Ceil(3 * sigma)
 }
 
-func isBiggerThenNeighbours(val float64, neighbour1 float64, neighbour2 float64) bool {
-	return val > neighbour1 && val > neighbour2
+func cannyEdges(g, t *floatImage.Gray, opts CannyOptions) (*image.Gray, Thresholds) {
+	theta := discreteOrientations(t)
+	thinEdges := nonMaxSuppression(g, theta)
+	thresholds := chooseThresholds(thinEdges, g, opts)
+	return hysteresis(doubleThreshold(thinEdges, g, thresholds.Low, thresholds.High)), thresholds
 }
 
-func nonMaxSuppression(img *image.Gray, g [][]float64, theta [][]float64) *image.Gray {
-	size := img.Bounds().Size()
-	thinEdges := image.NewGray(image.Rect(0, 0, size.X, size.Y))
-	utils.ForEachPixel(size, func(x, y int) {
-		isLocalMax := false
-		if x > 0 && x < size.X-1 && y > 0 && y < size.Y-1 {
-			switch theta[x][y] {
-			case 45:
-				if isBiggerThenNeighbours(g[x][y], g[x+1][y-1], g[x-1][y+1]) {
-					isLocalMax = true
-				}
-			case 90:
-				if isBiggerThenNeighbours(g[x][y], g[x+1][y], g[x-1][y]) {
-					isLocalMax = true
-				}
-			case 135:
-				if isBiggerThenNeighbours(g[x][y], g[x-1][y-1], g[x+1][y+1]) {
-					isLocalMax = true
-				}
-			case 0:
-				if isBiggerThenNeighbours(g[x][y], g[x][y+1], g[x][y-1]) {
-					isLocalMax = true
-				}
+func chooseThresholds(thinEdges *image.Gray, g *floatImage.Gray, opts CannyOptions) Thresholds {
+	if opts.Auto == ThresholdManual {
+		return Thresholds{Low: opts.Low, High: opts.High}
+	}
+
+	magnitudes := make([]float64, 0)
+	for y := thinEdges.Rect.Min.Y; y < thinEdges.Rect.Max.Y; y++ {
+		for x := thinEdges.Rect.Min.X; x < thinEdges.Rect.Max.X; x++ {
+			if thinEdges.GrayAt(x, y).Y == utils.MaxUint8 {
+				magnitudes = append(magnitudes, g.FloatAt(x, y))
 			}
 		}
+	}
+
+	if opts.Auto == ThresholdOtsu {
+		high := utils.OtsuThreshold(magnitudes, 256)
+		return Thresholds{Low: high / 2, High: high}
+	}
+	median := utils.Median(magnitudes)
+	return Thresholds{Low: (1 - opts.MedianSpread) * median, High: (1 + opts.MedianSpread) * median}
+}
+
+// isLocalMaximum breaks ties towards the first neighbour, so a ridge that is
+// two pixels wide with equal magnitudes still keeps one of them.
+func isLocalMaximum(val float64, neighbour1 float64, neighbour2 float64) bool {
+	return val > neighbour1 && val >= neighbour2
+}
+
+func nonMaxSuppression(g *floatImage.Gray, theta *floatImage.Gray) *image.Gray {
+	rect := g.Rect
+	thinEdges := image.NewGray(rect)
+	parallel.ForEachPixel(rect.Inset(1), func(x, y int) {
+		isLocalMax := false
+		val := g.FloatAt(x, y)
+		// The neighbours are taken along the gradient, which is across the edge.
+		switch theta.FloatAt(x, y) {
+		case 45:
+			isLocalMax = isLocalMaximum(val, g.FloatAt(x+1, y+1), g.FloatAt(x-1, y-1))
+		case 90:
+			isLocalMax = isLocalMaximum(val, g.FloatAt(x, y+1), g.FloatAt(x, y-1))
+		case 135:
+			isLocalMax = isLocalMaximum(val, g.FloatAt(x-1, y+1), g.FloatAt(x+1, y-1))
+		case 0:
+			isLocalMax = isLocalMaximum(val, g.FloatAt(x+1, y), g.FloatAt(x-1, y))
+		}
 		if isLocalMax {
 			thinEdges.SetGray(x, y, color.Gray{Y: utils.MaxUint8})
 		}
@@ -77,30 +220,60 @@ func nonMaxSuppression(img *image.Gray, g [][]float64, theta [][]float64) *image
 	return thinEdges
 }
 
-func doubleThreshold(img *image.Gray, g [][]float64, upperBound float64) *image.Gray {
-	size := img.Bounds().Size()
+// doubleThreshold labels the thin edges as strong (MaxUint8) when their
+// magnitude reaches upperBound and weak (MidUint8) when it reaches lowerBound.
+func doubleThreshold(img *image.Gray, g *floatImage.Gray, lowerBound, upperBound float64) *image.Gray {
 	res := image.NewGray(img.Rect)
-	utils.ForEachPixel(size, func(x int, y int) {
-		p := img.GrayAt(x, y)
-		if p.Y == utils.MaxUint8 {
-			if g[x][y] > upperBound {
-				res.SetGray(x, y, color.Gray{Y: utils.MaxUint8})
-			} else {
-				res.SetGray(x, y, color.Gray{Y: utils.MinUint8})
-			}
+	parallel.ForEachPixel(img.Rect, func(x int, y int) {
+		if img.GrayAt(x, y).Y != utils.MaxUint8 {
+			return
+		}
+		if val := g.FloatAt(x, y); val >= upperBound {
+			res.SetGray(x, y, color.Gray{Y: utils.MaxUint8})
+		} else if val >= lowerBound {
+			res.SetGray(x, y, color.Gray{Y: utils.MidUint8})
 		}
 	})
 	return res
 }
 
-func discreteOrientations(size image.Point, t [][]float64) [][]float64 {
-	theta := make([][]float64, size.X)
-	for i := 0; i < size.X; i++ {
-		theta[i] = make([]float64, size.Y)
+// hysteresis keeps the strong edges and every weak edge that is 8-connected
+// to one of them.
+func hysteresis(labels *image.Gray) *image.Gray {
+	rect := labels.Rect
+	res := image.NewGray(rect)
+	stack := make([]image.Point, 0)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			if labels.GrayAt(x, y).Y == utils.MaxUint8 {
+				res.SetGray(x, y, color.Gray{Y: utils.MaxUint8})
+				stack = append(stack, image.Point{x, y})
+			}
+		}
 	}
-	utils.ForEachPixel(size, func(x, y int) {
-		theta[x][y], _ = utils.DiscreteOrientation(t[x][y])
-	})
 
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				q := image.Point{p.X + dx, p.Y + dy}
+				if !q.In(rect) || res.GrayAt(q.X, q.Y).Y != 0 || labels.GrayAt(q.X, q.Y).Y != utils.MidUint8 {
+					continue
+				}
+				res.SetGray(q.X, q.Y, color.Gray{Y: utils.MaxUint8})
+				stack = append(stack, q)
+			}
+		}
+	}
+	return res
+}
+
+func discreteOrientations(t *floatImage.Gray) *floatImage.Gray {
+	theta := floatImage.NewGray(t.Rect)
+	parallel.ForEachPixel(t.Rect, func(x, y int) {
+		d, _ := utils.DiscreteOrientation(t.FloatAt(x, y))
+		theta.SetFloat(x, y, d)
+	})
 	return theta
 }
@@ -3,26 +3,51 @@ package edgeDetection
 import (
 	"image"
 
+	"github.com/alidadar7676/ComputerVision/floatImage"
 	"github.com/alidadar7676/ComputerVision/gradient"
 	"github.com/alidadar7676/ComputerVision/utils"
 )
 
 func SobelGray(img *image.Gray) (*image.Gray, error) {
-	hor, err := gradient.Horizontal(img)
+	hor, ver, err := sobelGradients(floatImage.FromGray(img))
 	if err != nil {
 		return nil, err
 	}
+	vertical := utils.CreateGrayImage(ver)
+	horizontal := utils.CreateGrayImage(hor)
 
-	ver, err := gradient.Vertical(img)
+	res, err := utils.AddGrayWeighted(horizontal, 0.5, vertical, 0.5)
 	if err != nil {
 		return nil, err
 	}
-	vertical := utils.CreateGrayImage(ver, img.Rect)
-	horizontal := utils.CreateGrayImage(hor, img.Rect)
+	return res, nil
+}
 
-	res, err := utils.AddGrayWeighted(horizontal, 0.5, vertical, 0.5)
+// SobelGray16 is SobelGray for 16 bit images; the result keeps 16 bits.
+func SobelGray16(img *image.Gray16) (*image.Gray16, error) {
+	hor, ver, err := sobelGradients(floatImage.FromGray16(img))
 	if err != nil {
 		return nil, err
 	}
-	return res, nil
+	return utils.AddGray16Weighted(utils.CreateGray16Image(hor), 0.5, utils.CreateGray16Image(ver), 0.5)
+}
+
+func sobelGradients(src *floatImage.Gray) (*floatImage.Gray, *floatImage.Gray, error) {
+	hor, err := gradient.Horizontal(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	ver, err := gradient.Vertical(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	return hor, ver, nil
+}
+
+func SobelColor(img image.Image) (*image.Gray, error) {
+	g, _, err := gradient.DiZenzo(floatImage.FromColor(img), 3)
+	if err != nil {
+		return nil, err
+	}
+	return utils.CreateGrayImage(g), nil
 }
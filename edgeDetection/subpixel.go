@@ -0,0 +1,106 @@
+package edgeDetection
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/alidadar7676/ComputerVision/floatImage"
+	"github.com/alidadar7676/ComputerVision/gradient"
+	"github.com/alidadar7676/ComputerVision/parallel"
+	"github.com/alidadar7676/ComputerVision/utils"
+)
+
+// EdgePoint is an edge location with sub-pixel accuracy. Integer coordinates
+// are pixel centers. (NormalX, NormalY) is the unit gradient direction, which
+// is perpendicular to the edge and points towards the brighter side.
+type EdgePoint struct {
+	X         float64
+	Y         float64
+	NormalX   float64
+	NormalY   float64
+	Magnitude float64
+}
+
+// SubpixelEdgesGray detects edges like CannyGray, but suppresses non-maxima
+// along the exact gradient direction and moves every edge pixel to the vertex
+// of the parabola through the magnitudes interpolated one pixel before and
+// after it. The gradient magnitude is always Euclidean.
+func SubpixelEdgesGray(img *image.Gray, opts CannyOptions) ([]EdgePoint, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	blurred, err := cannyBlur(floatImage.FromGray(img), opts.Sigma)
+	if err != nil {
+		return nil, err
+	}
+	dx, dy, err := gradient.Sobel(blurred, opts.ApertureSize)
+	if err != nil {
+		return nil, err
+	}
+	g, _ := gradient.GradientAndOrientation(dx, dy)
+
+	thinEdges := interpolatedNonMaxSuppression(g, dx, dy)
+	thresholds := chooseThresholds(thinEdges, g, opts)
+	edges := hysteresis(doubleThreshold(thinEdges, g, thresholds.Low, thresholds.High))
+
+	parts := parallel.Split(edges.Rect)
+	found := make([][]EdgePoint, len(parts))
+	parallel.Run(len(parts), func(i int) {
+		r := parts[i]
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				if edges.GrayAt(x, y).Y == utils.MaxUint8 {
+					found[i] = append(found[i], refineEdge(g, dx, dy, x, y))
+				}
+			}
+		}
+	})
+
+	points := make([]EdgePoint, 0)
+	for _, p := range found {
+		points = append(points, p...)
+	}
+	return points, nil
+}
+
+func refineEdge(g, dx, dy *floatImage.Gray, x, y int) EdgePoint {
+	m0 := g.FloatAt(x, y)
+	nx, ny := dx.FloatAt(x, y)/m0, dy.FloatAt(x, y)/m0
+	before := g.Bilinear(float64(x)-nx, float64(y)-ny)
+	after := g.Bilinear(float64(x)+nx, float64(y)+ny)
+
+	offset := 0.0
+	if den := before - 2*m0 + after; den < 0 {
+		offset = utils.Clamp(0.5*(before-after)/den, -0.5, 0.5)
+	}
+	return EdgePoint{
+		X:         float64(x) + offset*nx,
+		Y:         float64(y) + offset*ny,
+		NormalX:   nx,
+		NormalY:   ny,
+		Magnitude: m0 - 0.25*(before-after)*offset,
+	}
+}
+
+func interpolatedNonMaxSuppression(g, dx, dy *floatImage.Gray) *image.Gray {
+	thinEdges := image.NewGray(g.Rect)
+	parallel.ForEachPixel(g.Rect.Inset(1), func(x, y int) {
+		val := g.FloatAt(x, y)
+		if val == 0 {
+			return
+		}
+		nx, ny := dx.FloatAt(x, y)/val, dy.FloatAt(x, y)/val
+		before := g.Bilinear(float64(x)-nx, float64(y)-ny)
+		after := g.Bilinear(float64(x)+nx, float64(y)+ny)
+		if isLocalMaximum(val, after, before) {
+			thinEdges.SetGray(x, y, color.Gray{Y: utils.MaxUint8})
+		}
+	})
+	return thinEdges
+}
+
+// Angle returns the direction of the edge normal in radians.
+func (p EdgePoint) Angle() float64 {
+	return math.Atan2(p.NormalY, p.NormalX)
+}
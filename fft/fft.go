@@ -0,0 +1,167 @@
+package fft
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+
+	"github.com/alidadar7676/ComputerVision/parallel"
+)
+
+// FFT returns the discrete Fourier transform of x. Power of two lengths use the
+// iterative radix-2 algorithm, every other length goes through Bluestein's
+// chirp-z transform, so the cost is O(n log n) for any n.
+func FFT(x []complex128) []complex128 {
+	res := append([]complex128(nil), x...)
+	transform(res, false)
+	return res
+}
+
+// IFFT returns the inverse transform of x, scaled by 1/n so that
+// IFFT(FFT(x)) == x.
+func IFFT(x []complex128) []complex128 {
+	res := append([]complex128(nil), x...)
+	transform(res, true)
+	scale(res)
+	return res
+}
+
+// FFT2 transforms a row-major width x height matrix.
+func FFT2(data []complex128, width, height int) ([]complex128, error) {
+	if len(data) != width*height {
+		return nil, errors.New("The size of the data does not match the dimensions")
+	}
+	res := append([]complex128(nil), data...)
+	transform2(res, width, height, false)
+	return res, nil
+}
+
+func IFFT2(data []complex128, width, height int) ([]complex128, error) {
+	if len(data) != width*height {
+		return nil, errors.New("The size of the data does not match the dimensions")
+	}
+	res := append([]complex128(nil), data...)
+	transform2(res, width, height, true)
+	scale(res)
+	return res, nil
+}
+
+func NextPowerOfTwo(n int) int {
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
+
+func transform2(data []complex128, width, height int, inverse bool) {
+	parallel.Run(height, func(y int) {
+		transform(data[y*width:(y+1)*width], inverse)
+	})
+	parallel.Run(width, func(x int) {
+		column := make([]complex128, height)
+		for y := range column {
+			column[y] = data[y*width+x]
+		}
+		transform(column, inverse)
+		for y := range column {
+			data[y*width+x] = column[y]
+		}
+	})
+}
+
+func transform(x []complex128, inverse bool) {
+	n := len(x)
+	if n <= 1 {
+		return
+	}
+	if n&(n-1) == 0 {
+		radix2(x, inverse)
+	} else {
+		bluestein(x, inverse)
+	}
+}
+
+func radix2(x []complex128, inverse bool) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	sign := -1.0
+	if inverse {
+		sign = 1.0
+	}
+	twiddle := make([]complex128, n/2)
+	for k := range twiddle {
+		sin, cos := math.Sincos(sign * 2 * math.Pi * float64(k) / float64(n))
+		twiddle[k] = complex(cos, sin)
+	}
+
+	for length := 2; length <= n; length <<= 1 {
+		half := length / 2
+		step := n / length
+		for start := 0; start < n; start += length {
+			for k := 0; k < half; k++ {
+				w := twiddle[k*step]
+				a := x[start+k]
+				b := x[start+k+half] * w
+				x[start+k] = a + b
+				x[start+k+half] = a - b
+			}
+		}
+	}
+}
+
+func bluestein(x []complex128, inverse bool) {
+	n := len(x)
+	m := NextPowerOfTwo(2*n - 1)
+
+	sign := -1.0
+	if inverse {
+		sign = 1.0
+	}
+	chirp := make([]complex128, n)
+	for k := range chirp {
+		// k*k mod 2n keeps the angle small, which keeps it accurate.
+		kk := (int64(k) * int64(k)) % int64(2*n)
+		sin, cos := math.Sincos(sign * math.Pi * float64(kk) / float64(n))
+		chirp[k] = complex(cos, sin)
+	}
+
+	a := make([]complex128, m)
+	b := make([]complex128, m)
+	for k := 0; k < n; k++ {
+		a[k] = x[k] * chirp[k]
+	}
+	b[0] = cmplx.Conj(chirp[0])
+	for k := 1; k < n; k++ {
+		b[k] = cmplx.Conj(chirp[k])
+		b[m-k] = b[k]
+	}
+
+	radix2(a, false)
+	radix2(b, false)
+	for i := range a {
+		a[i] *= b[i]
+	}
+	radix2(a, true)
+
+	for k := 0; k < n; k++ {
+		x[k] = a[k] / complex(float64(m), 0) * chirp[k]
+	}
+}
+
+func scale(x []complex128) {
+	f := complex(1/float64(len(x)), 0)
+	for i := range x {
+		x[i] *= f
+	}
+}
@@ -0,0 +1,92 @@
+package fft
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+)
+
+// dft is the O(n^2) definition the fast transforms are checked against.
+func dft(x []complex128) []complex128 {
+	n := len(x)
+	res := make([]complex128, n)
+	for k := range res {
+		for j, v := range x {
+			res[k] += v * cmplx.Exp(complex(0, -2*math.Pi*float64(j*k)/float64(n)))
+		}
+	}
+	return res
+}
+
+func randomSignal(r *rand.Rand, n int) []complex128 {
+	x := make([]complex128, n)
+	for i := range x {
+		x[i] = complex(r.Float64()-0.5, r.Float64()-0.5)
+	}
+	return x
+}
+
+func maxDifference(a, b []complex128) float64 {
+	max := 0.0
+	for i := range a {
+		max = math.Max(max, cmplx.Abs(a[i]-b[i]))
+	}
+	return max
+}
+
+// TestFFT covers the radix-2 lengths and, for every other length, the
+// Bluestein transform.
+func TestFFT(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 2, 3, 5, 7, 8, 12, 17, 31, 64, 100, 127} {
+		x := randomSignal(r, n)
+		if diff := maxDifference(FFT(x), dft(x)); diff > 1e-9*float64(n) {
+			t.Errorf("FFT of length %d: difference %g", n, diff)
+		}
+		if diff := maxDifference(IFFT(FFT(x)), x); diff > 1e-12*float64(n) {
+			t.Errorf("IFFT(FFT(x)) of length %d: difference %g", n, diff)
+		}
+	}
+}
+
+func TestFFT2(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for _, size := range [][2]int{{8, 4}, {7, 5}, {16, 9}} {
+		width, height := size[0], size[1]
+		data := randomSignal(r, width*height)
+		res, err := FFT2(data, width, height)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The 2D transform is the 1D transform of the rows, then the columns.
+		want := make([]complex128, len(data))
+		for y := 0; y < height; y++ {
+			copy(want[y*width:], dft(data[y*width:(y+1)*width]))
+		}
+		for x := 0; x < width; x++ {
+			column := make([]complex128, height)
+			for y := range column {
+				column[y] = want[y*width+x]
+			}
+			for y, v := range dft(column) {
+				want[y*width+x] = v
+			}
+		}
+		if diff := maxDifference(res, want); diff > 1e-9*float64(len(data)) {
+			t.Errorf("FFT2 of %dx%d: difference %g", width, height, diff)
+		}
+
+		back, err := IFFT2(res, width, height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := maxDifference(back, data); diff > 1e-12*float64(len(data)) {
+			t.Errorf("IFFT2(FFT2(x)) of %dx%d: difference %g", width, height, diff)
+		}
+	}
+	if _, err := FFT2(make([]complex128, 5), 2, 2); err == nil {
+		t.Error("FFT2 accepted data of the wrong size")
+	}
+}
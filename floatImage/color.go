@@ -0,0 +1,82 @@
+package floatImage
+
+import (
+	"image"
+	"image/color"
+)
+
+// FromColor converts img to a four channel (R, G, B, A) image holding non
+// alpha-premultiplied values in [0, 255].
+func FromColor(img image.Image) *Multi {
+	bounds := img.Bounds()
+	res := NewMulti(bounds, 4)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			var c color.NRGBA
+			switch src := img.(type) {
+			case *image.NRGBA:
+				c = src.NRGBAAt(x, y)
+			default:
+				c = color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			}
+			i := res.PixOffset(x, y)
+			res.Pix[i+0] = float64(c.R)
+			res.Pix[i+1] = float64(c.G)
+			res.Pix[i+2] = float64(c.B)
+			res.Pix[i+3] = float64(c.A)
+		}
+	}
+	return res
+}
+
+func (m *Multi) ToNRGBA() *image.NRGBA {
+	res := image.NewNRGBA(m.Rect)
+	for y := m.Rect.Min.Y; y < m.Rect.Max.Y; y++ {
+		for x := m.Rect.Min.X; x < m.Rect.Max.X; x++ {
+			res.SetNRGBA(x, y, m.At(x, y).(color.NRGBA))
+		}
+	}
+	return res
+}
+
+func (m *Multi) ToRGBA() *image.RGBA {
+	res := image.NewRGBA(m.Rect)
+	for y := m.Rect.Min.Y; y < m.Rect.Max.Y; y++ {
+		for x := m.Rect.Min.X; x < m.Rect.Max.X; x++ {
+			res.Set(x, y, m.At(x, y))
+		}
+	}
+	return res
+}
+
+// Premultiply multiplies the color channels of a four channel image holding
+// values in [0, 255] by their alpha, so that filters do not pick up the color
+// of transparent pixels.
+func (m *Multi) Premultiply() {
+	for y := m.Rect.Min.Y; y < m.Rect.Max.Y; y++ {
+		for x := m.Rect.Min.X; x < m.Rect.Max.X; x++ {
+			i := m.PixOffset(x, y)
+			a := m.Pix[i+3] / 255
+			for c := 0; c < 3; c++ {
+				m.Pix[i+c] *= a
+			}
+		}
+	}
+}
+
+// Unpremultiply undoes Premultiply. Fully transparent pixels become black.
+func (m *Multi) Unpremultiply() {
+	for y := m.Rect.Min.Y; y < m.Rect.Max.Y; y++ {
+		for x := m.Rect.Min.X; x < m.Rect.Max.X; x++ {
+			i := m.PixOffset(x, y)
+			a := m.Pix[i+3]
+			for c := 0; c < 3; c++ {
+				if a > 0 {
+					m.Pix[i+c] *= 255 / a
+				} else {
+					m.Pix[i+c] = 0
+				}
+			}
+		}
+	}
+}
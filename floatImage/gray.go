@@ -0,0 +1,144 @@
+package floatImage
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Gray is a single channel image whose pixels are float64 values. Values are
+// not clamped, so negative and out of range results survive between stages.
+type Gray struct {
+	Pix    []float64
+	Stride int
+	Rect   image.Rectangle
+}
+
+func NewGray(r image.Rectangle) *Gray {
+	w, h := r.Dx(), r.Dy()
+	return &Gray{Pix: make([]float64, w*h), Stride: w, Rect: r}
+}
+
+func FromGray(img *image.Gray) *Gray {
+	res := NewGray(img.Rect)
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			res.Pix[res.PixOffset(x, y)] = float64(img.Pix[img.PixOffset(x, y)])
+		}
+	}
+	return res
+}
+
+func FromImage(img image.Image) *Gray {
+	if gray, ok := img.(*image.Gray); ok {
+		return FromGray(gray)
+	}
+	bounds := img.Bounds()
+	res := NewGray(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			res.Pix[res.PixOffset(x, y)] = float64(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+		}
+	}
+	return res
+}
+
+func (g *Gray) ColorModel() color.Model {
+	return color.GrayModel
+}
+
+func (g *Gray) Bounds() image.Rectangle {
+	return g.Rect
+}
+
+func (g *Gray) At(x, y int) color.Color {
+	return color.Gray{Y: clampUint8(g.FloatAt(x, y))}
+}
+
+func (g *Gray) FloatAt(x, y int) float64 {
+	if !(image.Point{x, y}.In(g.Rect)) {
+		return 0
+	}
+	return g.Pix[g.PixOffset(x, y)]
+}
+
+func (g *Gray) PixOffset(x, y int) int {
+	return (y-g.Rect.Min.Y)*g.Stride + (x - g.Rect.Min.X)
+}
+
+func (g *Gray) Set(x, y int, c color.Color) {
+	g.SetFloat(x, y, float64(color.GrayModel.Convert(c).(color.Gray).Y))
+}
+
+func (g *Gray) SetFloat(x, y int, value float64) {
+	if !(image.Point{x, y}.In(g.Rect)) {
+		return
+	}
+	g.Pix[g.PixOffset(x, y)] = value
+}
+
+func (g *Gray) SubImage(r image.Rectangle) *Gray {
+	r = r.Intersect(g.Rect)
+	if r.Empty() {
+		return &Gray{}
+	}
+	i := g.PixOffset(r.Min.X, r.Min.Y)
+	return &Gray{Pix: g.Pix[i:], Stride: g.Stride, Rect: r}
+}
+
+func (g *Gray) Clone() *Gray {
+	res := NewGray(g.Rect)
+	for y := g.Rect.Min.Y; y < g.Rect.Max.Y; y++ {
+		copy(res.Pix[res.PixOffset(g.Rect.Min.X, y):res.PixOffset(g.Rect.Max.X, y)], g.Pix[g.PixOffset(g.Rect.Min.X, y):])
+	}
+	return res
+}
+
+func (g *Gray) MinMax() (float64, float64) {
+	min, max := math.Inf(1), math.Inf(-1)
+	for y := g.Rect.Min.Y; y < g.Rect.Max.Y; y++ {
+		for x := g.Rect.Min.X; x < g.Rect.Max.X; x++ {
+			v := g.Pix[g.PixOffset(x, y)]
+			min = math.Min(min, v)
+			max = math.Max(max, v)
+		}
+	}
+	return min, max
+}
+
+// ToGray converts the image back to 8 bits, clamping every value to [0, 255].
+func (g *Gray) ToGray() *image.Gray {
+	res := image.NewGray(g.Rect)
+	for y := g.Rect.Min.Y; y < g.Rect.Max.Y; y++ {
+		for x := g.Rect.Min.X; x < g.Rect.Max.X; x++ {
+			res.Pix[res.PixOffset(x, y)] = clampUint8(g.Pix[g.PixOffset(x, y)])
+		}
+	}
+	return res
+}
+
+// Normalized linearly stretches the image to [0, 255], which is how signed
+// intermediate results such as DoG layers are meant to be visualized.
+func (g *Gray) Normalized() *image.Gray {
+	min, max := g.MinMax()
+	scale := 0.0
+	if max > min {
+		scale = 255 / (max - min)
+	}
+	res := image.NewGray(g.Rect)
+	for y := g.Rect.Min.Y; y < g.Rect.Max.Y; y++ {
+		for x := g.Rect.Min.X; x < g.Rect.Max.X; x++ {
+			res.Pix[res.PixOffset(x, y)] = clampUint8((g.Pix[g.PixOffset(x, y)] - min) * scale)
+		}
+	}
+	return res
+}
+
+func clampUint8(value float64) uint8 {
+	if value < 0 {
+		return 0
+	} else if value > 255 {
+		return 255
+	}
+	return uint8(value)
+}
@@ -0,0 +1,37 @@
+package floatImage
+
+import "image"
+
+func FromGray16(img *image.Gray16) *Gray {
+	res := NewGray(img.Rect)
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			res.Pix[res.PixOffset(x, y)] = float64(img.Gray16At(x, y).Y)
+		}
+	}
+	return res
+}
+
+// ToGray16 converts the image to 16 bits, clamping every value to
+// [0, 65535]. Unlike ToGray it keeps the full range of 16 bit inputs.
+func (g *Gray) ToGray16() *image.Gray16 {
+	res := image.NewGray16(g.Rect)
+	for y := g.Rect.Min.Y; y < g.Rect.Max.Y; y++ {
+		for x := g.Rect.Min.X; x < g.Rect.Max.X; x++ {
+			v := clampUint16(g.Pix[g.PixOffset(x, y)])
+			i := res.PixOffset(x, y)
+			res.Pix[i+0] = uint8(v >> 8)
+			res.Pix[i+1] = uint8(v)
+		}
+	}
+	return res
+}
+
+func clampUint16(value float64) uint16 {
+	if value < 0 {
+		return 0
+	} else if value > 65535 {
+		return 65535
+	}
+	return uint16(value)
+}
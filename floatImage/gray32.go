@@ -0,0 +1,82 @@
+package floatImage
+
+import (
+	"image"
+	"image/color"
+)
+
+// Gray32 is the float32 counterpart of Gray, for large buffers where half the
+// memory matters more than the extra precision.
+type Gray32 struct {
+	Pix    []float32
+	Stride int
+	Rect   image.Rectangle
+}
+
+func NewGray32(r image.Rectangle) *Gray32 {
+	w, h := r.Dx(), r.Dy()
+	return &Gray32{Pix: make([]float32, w*h), Stride: w, Rect: r}
+}
+
+func (g *Gray) ToGray32() *Gray32 {
+	res := NewGray32(g.Rect)
+	for y := g.Rect.Min.Y; y < g.Rect.Max.Y; y++ {
+		for x := g.Rect.Min.X; x < g.Rect.Max.X; x++ {
+			res.Pix[res.PixOffset(x, y)] = float32(g.Pix[g.PixOffset(x, y)])
+		}
+	}
+	return res
+}
+
+func (g *Gray32) ToGray64() *Gray {
+	res := NewGray(g.Rect)
+	for y := g.Rect.Min.Y; y < g.Rect.Max.Y; y++ {
+		for x := g.Rect.Min.X; x < g.Rect.Max.X; x++ {
+			res.Pix[res.PixOffset(x, y)] = float64(g.Pix[g.PixOffset(x, y)])
+		}
+	}
+	return res
+}
+
+func (g *Gray32) ColorModel() color.Model {
+	return color.GrayModel
+}
+
+func (g *Gray32) Bounds() image.Rectangle {
+	return g.Rect
+}
+
+func (g *Gray32) At(x, y int) color.Color {
+	return color.Gray{Y: clampUint8(float64(g.FloatAt(x, y)))}
+}
+
+func (g *Gray32) FloatAt(x, y int) float32 {
+	if !(image.Point{x, y}.In(g.Rect)) {
+		return 0
+	}
+	return g.Pix[g.PixOffset(x, y)]
+}
+
+func (g *Gray32) PixOffset(x, y int) int {
+	return (y-g.Rect.Min.Y)*g.Stride + (x - g.Rect.Min.X)
+}
+
+func (g *Gray32) Set(x, y int, c color.Color) {
+	g.SetFloat(x, y, float32(color.GrayModel.Convert(c).(color.Gray).Y))
+}
+
+func (g *Gray32) SetFloat(x, y int, value float32) {
+	if !(image.Point{x, y}.In(g.Rect)) {
+		return
+	}
+	g.Pix[g.PixOffset(x, y)] = value
+}
+
+func (g *Gray32) SubImage(r image.Rectangle) *Gray32 {
+	r = r.Intersect(g.Rect)
+	if r.Empty() {
+		return &Gray32{}
+	}
+	i := g.PixOffset(r.Min.X, r.Min.Y)
+	return &Gray32{Pix: g.Pix[i:], Stride: g.Stride, Rect: r}
+}
@@ -0,0 +1,25 @@
+package floatImage
+
+import "math"
+
+// Bilinear samples the image at a real position, where integer coordinates
+// are pixel centers. Positions outside the image are clamped to the border.
+func (g *Gray) Bilinear(x, y float64) float64 {
+	if g.Rect.Empty() {
+		return 0
+	}
+	x = math.Max(float64(g.Rect.Min.X), math.Min(x, float64(g.Rect.Max.X-1)))
+	y = math.Max(float64(g.Rect.Min.Y), math.Min(y, float64(g.Rect.Max.Y-1)))
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	x1, y1 := x0+1, y0+1
+	if x1 >= g.Rect.Max.X {
+		x1 = x0
+	}
+	if y1 >= g.Rect.Max.Y {
+		y1 = y0
+	}
+	fx, fy := x-float64(x0), y-float64(y0)
+	top := g.Pix[g.PixOffset(x0, y0)]*(1-fx) + g.Pix[g.PixOffset(x1, y0)]*fx
+	bottom := g.Pix[g.PixOffset(x0, y1)]*(1-fx) + g.Pix[g.PixOffset(x1, y1)]*fx
+	return top*(1-fy) + bottom*fy
+}
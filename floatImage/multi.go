@@ -0,0 +1,95 @@
+package floatImage
+
+import (
+	"errors"
+	"image"
+	"image/color"
+)
+
+// Multi is an interleaved multi channel float64 image. Pixel (x, y) occupies
+// Channels consecutive values starting at PixOffset(x, y).
+type Multi struct {
+	Pix      []float64
+	Stride   int
+	Rect     image.Rectangle
+	Channels int
+}
+
+func NewMulti(r image.Rectangle, channels int) *Multi {
+	w, h := r.Dx(), r.Dy()
+	return &Multi{Pix: make([]float64, w*h*channels), Stride: w * channels, Rect: r, Channels: channels}
+}
+
+func (m *Multi) ColorModel() color.Model {
+	if m.Channels < 3 {
+		return color.GrayModel
+	}
+	return color.NRGBAModel
+}
+
+func (m *Multi) Bounds() image.Rectangle {
+	return m.Rect
+}
+
+func (m *Multi) At(x, y int) color.Color {
+	if m.Channels < 3 {
+		return color.Gray{Y: clampUint8(m.FloatAt(x, y, 0))}
+	}
+	a := uint8(255)
+	if m.Channels > 3 {
+		a = clampUint8(m.FloatAt(x, y, 3))
+	}
+	return color.NRGBA{R: clampUint8(m.FloatAt(x, y, 0)), G: clampUint8(m.FloatAt(x, y, 1)), B: clampUint8(m.FloatAt(x, y, 2)), A: a}
+}
+
+func (m *Multi) FloatAt(x, y, c int) float64 {
+	if !(image.Point{x, y}.In(m.Rect)) || c < 0 || c >= m.Channels {
+		return 0
+	}
+	return m.Pix[m.PixOffset(x, y)+c]
+}
+
+func (m *Multi) PixOffset(x, y int) int {
+	return (y-m.Rect.Min.Y)*m.Stride + (x-m.Rect.Min.X)*m.Channels
+}
+
+func (m *Multi) SetFloat(x, y, c int, value float64) {
+	if !(image.Point{x, y}.In(m.Rect)) || c < 0 || c >= m.Channels {
+		return
+	}
+	m.Pix[m.PixOffset(x, y)+c] = value
+}
+
+func (m *Multi) SubImage(r image.Rectangle) *Multi {
+	r = r.Intersect(m.Rect)
+	if r.Empty() {
+		return &Multi{Channels: m.Channels}
+	}
+	i := m.PixOffset(r.Min.X, r.Min.Y)
+	return &Multi{Pix: m.Pix[i:], Stride: m.Stride, Rect: r, Channels: m.Channels}
+}
+
+func (m *Multi) Channel(c int) *Gray {
+	res := NewGray(m.Rect)
+	for y := m.Rect.Min.Y; y < m.Rect.Max.Y; y++ {
+		for x := m.Rect.Min.X; x < m.Rect.Max.X; x++ {
+			res.Pix[res.PixOffset(x, y)] = m.FloatAt(x, y, c)
+		}
+	}
+	return res
+}
+
+func (m *Multi) SetChannel(c int, g *Gray) error {
+	if c < 0 || c >= m.Channels {
+		return errors.New("Channel index out of range")
+	}
+	if g.Rect != m.Rect {
+		return errors.New("The size of the channel does not match")
+	}
+	for y := m.Rect.Min.Y; y < m.Rect.Max.Y; y++ {
+		for x := m.Rect.Min.X; x < m.Rect.Max.X; x++ {
+			m.Pix[m.PixOffset(x, y)+c] = g.Pix[g.PixOffset(x, y)]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package frequency
+
+import (
+	"errors"
+	"image"
+	"math"
+
+	"github.com/alidadar7676/ComputerVision/floatImage"
+)
+
+// Filter is a real transfer function of the signed frequency (u, v), see
+// Spectrum.Frequency.
+type Filter func(u, v float64) float64
+
+type Kind int
+
+const (
+	Ideal Kind = iota
+	Butterworth
+	Gaussian
+)
+
+// LowPass returns a low-pass filter with cutoff distance d0. The order is only
+// used by Butterworth filters. d0 must be positive and the order of a
+// Butterworth filter at least 1.
+func LowPass(kind Kind, d0 float64, order int) (Filter, error) {
+	if err := checkFilter(kind, d0, order); err != nil {
+		return nil, err
+	}
+	return func(u, v float64) float64 {
+		return lowPass(kind, math.Hypot(u, v), d0, order)
+	}, nil
+}
+
+func HighPass(kind Kind, d0 float64, order int) (Filter, error) {
+	f, err := LowPass(kind, d0, order)
+	if err != nil {
+		return nil, err
+	}
+	return Invert(f), nil
+}
+
+// BandReject removes the ring of frequencies at distance d0 with the given
+// width, which must be positive.
+func BandReject(kind Kind, d0 float64, width float64, order int) (Filter, error) {
+	if err := checkFilter(kind, d0, order); err != nil {
+		return nil, err
+	}
+	if !(width > 0) {
+		return nil, errors.New("Band width must be bigger then 0")
+	}
+	return func(u, v float64) float64 {
+		d := math.Hypot(u, v)
+		switch kind {
+		case Ideal:
+			if d >= d0-width/2 && d <= d0+width/2 {
+				return 0
+			}
+			return 1
+		case Butterworth:
+			den := d*d - d0*d0
+			if den == 0 {
+				return 0
+			}
+			return 1 / (1 + math.Pow(d*width/den, 2*float64(order)))
+		case Gaussian:
+			if d == 0 {
+				return 1
+			}
+			e := (d*d - d0*d0) / (d * width)
+			return 1 - math.Exp(-e*e)
+		}
+		return 1
+	}, nil
+}
+
+func BandPass(kind Kind, d0 float64, width float64, order int) (Filter, error) {
+	f, err := BandReject(kind, d0, width, order)
+	if err != nil {
+		return nil, err
+	}
+	return Invert(f), nil
+}
+
+// NotchReject removes the symmetric pair of frequencies (u0, v0) and
+// (-u0, -v0) with radius d0, which is how periodic noise is taken out.
+func NotchReject(kind Kind, u0, v0 float64, d0 float64, order int) (Filter, error) {
+	if err := checkFilter(kind, d0, order); err != nil {
+		return nil, err
+	}
+	return func(u, v float64) float64 {
+		return (1 - lowPass(kind, math.Hypot(u-u0, v-v0), d0, order)) *
+			(1 - lowPass(kind, math.Hypot(u+u0, v+v0), d0, order))
+	}, nil
+}
+
+func NotchPass(kind Kind, u0, v0 float64, d0 float64, order int) (Filter, error) {
+	f, err := NotchReject(kind, u0, v0, d0, order)
+	if err != nil {
+		return nil, err
+	}
+	return Invert(f), nil
+}
+
+func Invert(f Filter) Filter {
+	return func(u, v float64) float64 {
+		return 1 - f(u, v)
+	}
+}
+
+// Combine multiplies the responses, e.g. to reject several notches at once.
+func Combine(filters ...Filter) Filter {
+	return func(u, v float64) float64 {
+		res := 1.0
+		for _, f := range filters {
+			res *= f(u, v)
+		}
+		return res
+	}
+}
+
+func (s *Spectrum) Apply(f Filter) *Spectrum {
+	res := &Spectrum{Data: make([]complex128, len(s.Data)), Width: s.Width, Height: s.Height, Rect: s.Rect}
+	for v := 0; v < s.Height; v++ {
+		for u := 0; u < s.Width; u++ {
+			fu, fv := s.Frequency(u, v)
+			res.Data[v*s.Width+u] = s.Data[v*s.Width+u] * complex(f(fu, fv), 0)
+		}
+	}
+	return res
+}
+
+// ApplyFilter filters img in the frequency domain.
+func ApplyFilter(img *floatImage.Gray, f Filter) (*floatImage.Gray, error) {
+	if f == nil {
+		return nil, errors.New("Nil filter")
+	}
+	s, err := Forward(img)
+	if err != nil {
+		return nil, err
+	}
+	return s.Apply(f).Inverse()
+}
+
+func ApplyFilterGray(img *image.Gray, f Filter) (*image.Gray, error) {
+	res, err := ApplyFilter(floatImage.FromGray(img), f)
+	if err != nil {
+		return nil, err
+	}
+	return res.ToGray(), nil
+}
+
+// ResponseImage draws the centered transfer function of f for a width x height
+// spectrum.
+func ResponseImage(f Filter, width, height int) *image.Gray {
+	res := floatImage.NewGray(image.Rect(0, 0, width, height))
+	for v := 0; v < height; v++ {
+		for u := 0; u < width; u++ {
+			res.Pix[v*width+u] = 255 * f(float64(signedIndex(u, width)), float64(signedIndex(v, height)))
+		}
+	}
+	return Shift(res).ToGray()
+}
+
+func checkFilter(kind Kind, d0 float64, order int) error {
+	if kind != Ideal && kind != Butterworth && kind != Gaussian {
+		return errors.New("Unknown filter kind")
+	}
+	if !(d0 > 0) {
+		return errors.New("Cutoff distance must be bigger then 0")
+	}
+	if kind == Butterworth && order < 1 {
+		return errors.New("Butterworth order must be at least 1")
+	}
+	return nil
+}
+
+func lowPass(kind Kind, d float64, d0 float64, order int) float64 {
+	switch kind {
+	case Ideal:
+		if d <= d0 {
+			return 1
+		}
+		return 0
+	case Butterworth:
+		return 1 / (1 + math.Pow(d/d0, 2*float64(order)))
+	case Gaussian:
+		return math.Exp(-d * d / (2 * d0 * d0))
+	}
+	return 1
+}
@@ -0,0 +1,113 @@
+package frequency
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/alidadar7676/ComputerVision/floatImage"
+)
+
+const far = 1e9
+
+// TestFilterResponses checks the responses at distance 0, at the cutoff d0
+// and far away.
+func TestFilterResponses(t *testing.T) {
+	const d0 = 10
+	tests := []struct {
+		kind            Kind
+		zero, cut, away float64
+	}{
+		{Ideal, 1, 1, 0},
+		{Butterworth, 1, 0.5, 0},
+		{Gaussian, 1, math.Exp(-0.5), 0},
+	}
+	for _, test := range tests {
+		low, err := LowPass(test.kind, d0, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		high, err := HighPass(test.kind, d0, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range []struct{ d, want float64 }{{0, test.zero}, {d0, test.cut}, {far, test.away}} {
+			u, v := 0.6*p.d, 0.8*p.d
+			if got := low(u, v); math.Abs(got-p.want) > 1e-12 {
+				t.Errorf("low-pass %d at %g: %g, want %g", test.kind, p.d, got, p.want)
+			}
+			if got := high(u, v); math.Abs(got-(1-p.want)) > 1e-12 {
+				t.Errorf("high-pass %d at %g: %g, want %g", test.kind, p.d, got, 1-p.want)
+			}
+		}
+
+		band, err := BandReject(test.kind, d0, 4, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range []struct{ d, want float64 }{{0, 1}, {d0, 0}, {far, 1}} {
+			if got := band(p.d, 0); math.Abs(got-p.want) > 1e-9 {
+				t.Errorf("band-reject %d at %g: %g, want %g", test.kind, p.d, got, p.want)
+			}
+		}
+
+		notch, err := NotchReject(test.kind, 5, -3, 2, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range []struct{ u, v, want float64 }{{5, -3, 0}, {-5, 3, 0}, {far, far, 1}} {
+			if got := notch(p.u, p.v); math.Abs(got-p.want) > 1e-9 {
+				t.Errorf("notch %d at (%g, %g): %g, want %g", test.kind, p.u, p.v, got, p.want)
+			}
+		}
+	}
+}
+
+func TestFilterErrors(t *testing.T) {
+	for _, kind := range []Kind{Ideal, Butterworth, Gaussian} {
+		for _, d0 := range []float64{0, -1, math.NaN()} {
+			if _, err := LowPass(kind, d0, 2); err == nil {
+				t.Errorf("low-pass %d accepted d0 %g", kind, d0)
+			}
+			if _, err := NotchPass(kind, 1, 1, d0, 2); err == nil {
+				t.Errorf("notch-pass %d accepted d0 %g", kind, d0)
+			}
+		}
+		for _, width := range []float64{0, -2} {
+			if _, err := BandPass(kind, 10, width, 2); err == nil {
+				t.Errorf("band-pass %d accepted width %g", kind, width)
+			}
+		}
+	}
+	if _, err := HighPass(Butterworth, 10, 0); err == nil {
+		t.Error("Butterworth filter accepted order 0")
+	}
+}
+
+// TestShift checks that Shift centers the zero frequency and InverseShift
+// undoes it, for odd and even sizes.
+func TestShift(t *testing.T) {
+	for _, rect := range []image.Rectangle{
+		image.Rect(0, 0, 5, 3),
+		image.Rect(0, 0, 7, 8),
+		image.Rect(0, 0, 6, 4),
+		image.Rect(2, -1, 11, 6),
+	} {
+		img := floatImage.NewGray(rect)
+		for i := range img.Pix {
+			img.Pix[i] = float64(i)
+		}
+		shifted := Shift(img)
+		size := rect.Size()
+		center := rect.Min.Add(image.Pt(size.X/2, size.Y/2))
+		if got := shifted.FloatAt(center.X, center.Y); got != img.FloatAt(rect.Min.X, rect.Min.Y) {
+			t.Errorf("%v: center holds %g, want the zero frequency", rect, got)
+		}
+		back := InverseShift(shifted)
+		for i := range img.Pix {
+			if back.Pix[i] != img.Pix[i] {
+				t.Fatalf("%v: InverseShift(Shift(img)) differs at %d", rect, i)
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package frequency
+
+import (
+	"errors"
+	"image"
+	"math"
+	"math/cmplx"
+
+	"github.com/alidadar7676/ComputerVision/fft"
+	"github.com/alidadar7676/ComputerVision/floatImage"
+)
+
+// Spectrum is the 2D DFT of an image, stored row-major and unshifted: the DC
+// component is at index 0.
+type Spectrum struct {
+	Data   []complex128
+	Width  int
+	Height int
+	Rect   image.Rectangle
+}
+
+func Forward(img *floatImage.Gray) (*Spectrum, error) {
+	size := img.Rect.Size()
+	if size.X == 0 || size.Y == 0 {
+		return nil, errors.New("Empty image")
+	}
+	data := make([]complex128, size.X*size.Y)
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			data[y*size.X+x] = complex(img.FloatAt(img.Rect.Min.X+x, img.Rect.Min.Y+y), 0)
+		}
+	}
+	data, err := fft.FFT2(data, size.X, size.Y)
+	if err != nil {
+		return nil, err
+	}
+	return &Spectrum{Data: data, Width: size.X, Height: size.Y, Rect: img.Rect}, nil
+}
+
+func ForwardGray(img *image.Gray) (*Spectrum, error) {
+	return Forward(floatImage.FromGray(img))
+}
+
+// Inverse transforms the spectrum back and returns its real part.
+func (s *Spectrum) Inverse() (*floatImage.Gray, error) {
+	data, err := fft.IFFT2(s.Data, s.Width, s.Height)
+	if err != nil {
+		return nil, err
+	}
+	res := floatImage.NewGray(s.Rect)
+	for y := 0; y < s.Height; y++ {
+		for x := 0; x < s.Width; x++ {
+			res.SetFloat(s.Rect.Min.X+x, s.Rect.Min.Y+y, real(data[y*s.Width+x]))
+		}
+	}
+	return res, nil
+}
+
+func (s *Spectrum) At(u, v int) complex128 {
+	return s.Data[v*s.Width+u]
+}
+
+// Frequency returns the signed frequency of the sample at index (u, v), so
+// that (0, 0) is the DC component and the highest frequencies are at
+// +-Width/2 and +-Height/2.
+func (s *Spectrum) Frequency(u, v int) (float64, float64) {
+	return float64(signedIndex(u, s.Width)), float64(signedIndex(v, s.Height))
+}
+
+// Magnitude returns log(1 + |F|) in the unshifted layout.
+func (s *Spectrum) Magnitude() *floatImage.Gray {
+	return s.mapValues(func(c complex128) float64 {
+		return math.Log1p(cmplx.Abs(c))
+	})
+}
+
+func (s *Spectrum) Phase() *floatImage.Gray {
+	return s.mapValues(cmplx.Phase)
+}
+
+// MagnitudeImage returns the centered, contrast stretched log magnitude, the
+// usual way a spectrum is displayed.
+func (s *Spectrum) MagnitudeImage() *image.Gray {
+	return Shift(s.Magnitude()).Normalized()
+}
+
+func (s *Spectrum) PhaseImage() *image.Gray {
+	return Shift(s.Phase()).Normalized()
+}
+
+func (s *Spectrum) mapValues(f func(complex128) float64) *floatImage.Gray {
+	res := floatImage.NewGray(image.Rect(0, 0, s.Width, s.Height))
+	for i, c := range s.Data {
+		res.Pix[i] = f(c)
+	}
+	return res
+}
+
+// Shift moves the zero frequency to the center of the image, like fftshift.
+func Shift(img *floatImage.Gray) *floatImage.Gray {
+	size := img.Rect.Size()
+	return roll(img, size.X/2, size.Y/2)
+}
+
+// InverseShift undoes Shift, also for odd sizes.
+func InverseShift(img *floatImage.Gray) *floatImage.Gray {
+	size := img.Rect.Size()
+	return roll(img, (size.X+1)/2, (size.Y+1)/2)
+}
+
+func roll(img *floatImage.Gray, dx, dy int) *floatImage.Gray {
+	size := img.Rect.Size()
+	res := floatImage.NewGray(img.Rect)
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			tx := (x + dx) % size.X
+			ty := (y + dy) % size.Y
+			res.SetFloat(img.Rect.Min.X+tx, img.Rect.Min.Y+ty, img.FloatAt(img.Rect.Min.X+x, img.Rect.Min.Y+y))
+		}
+	}
+	return res
+}
+
+func signedIndex(k, n int) int {
+	if k > n/2 {
+		return k - n
+	}
+	return k
+}
@@ -0,0 +1,193 @@
+package geometry
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// Homography estimates the projective transform mapping src to dst with the
+// normalized direct linear transform. At least 4 correspondences are needed;
+// with more the algebraic error is minimized in least squares sense.
+func Homography(src, dst []Point) (Matrix, error) {
+	if err := checkCorrespondences(src, dst, 4); err != nil {
+		return Matrix{}, err
+	}
+	srcNorm, srcT := normalizePoints(src)
+	dstNorm, dstT := normalizePoints(dst)
+
+	// Accumulate A^T * A of the two DLT rows of every correspondence; h is
+	// its eigenvector of the smallest eigenvalue.
+	ata := make([][]float64, 9)
+	for i := range ata {
+		ata[i] = make([]float64, 9)
+	}
+	for i := range srcNorm {
+		x, y := srcNorm[i].X, srcNorm[i].Y
+		u, v := dstNorm[i].X, dstNorm[i].Y
+		rows := [2][9]float64{
+			{-x, -y, -1, 0, 0, 0, u * x, u * y, u},
+			{0, 0, 0, -x, -y, -1, v * x, v * y, v},
+		}
+		for _, row := range rows {
+			for r := 0; r < 9; r++ {
+				for c := r; c < 9; c++ {
+					ata[r][c] += row[r] * row[c]
+				}
+			}
+		}
+	}
+	for r := 0; r < 9; r++ {
+		for c := 0; c < r; c++ {
+			ata[r][c] = ata[c][r]
+		}
+	}
+
+	values, vectors := symmetricEigen(ata)
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return values[order[i]] < values[order[j]]
+	})
+	// A second null vector means the points, e.g. collinear ones, do not
+	// determine the homography.
+	if values[order[1]] < 1e-9*values[order[8]] {
+		return Matrix{}, errors.New("Degenerate correspondences")
+	}
+	smallest := order[0]
+	var h Matrix
+	for i := range h {
+		h[i] = vectors[i][smallest]
+	}
+
+	dstInv, err := dstT.Inverse()
+	if err != nil {
+		return Matrix{}, err
+	}
+	res := dstInv.Multiply(h).Multiply(srcT)
+	if math.Abs(res[8]) < 1e-12 {
+		return Matrix{}, errors.New("Degenerate correspondences")
+	}
+	res = res.normalized()
+	if _, err := res.Inverse(); err != nil {
+		return Matrix{}, errors.New("Degenerate correspondences")
+	}
+	return res, nil
+}
+
+// Affine estimates the affine transform mapping src to dst in least squares
+// sense from at least 3 correspondences.
+func Affine(src, dst []Point) (Matrix, error) {
+	if err := checkCorrespondences(src, dst, 3); err != nil {
+		return Matrix{}, err
+	}
+	srcNorm, srcT := normalizePoints(src)
+
+	// Both output coordinates are independent linear functions of (x, y, 1),
+	// so they share the normal matrix.
+	var normal [3][3]float64
+	var bx, by [3]float64
+	for i, p := range srcNorm {
+		row := [3]float64{p.X, p.Y, 1}
+		for r := 0; r < 3; r++ {
+			for c := 0; c < 3; c++ {
+				normal[r][c] += row[r] * row[c]
+			}
+			bx[r] += row[r] * dst[i].X
+			by[r] += row[r] * dst[i].Y
+		}
+	}
+	first, ok := solve3(normal, bx)
+	if !ok {
+		return Matrix{}, errors.New("Degenerate correspondences")
+	}
+	second, ok := solve3(normal, by)
+	if !ok {
+		return Matrix{}, errors.New("Degenerate correspondences")
+	}
+	res := Matrix{first[0], first[1], first[2], second[0], second[1], second[2], 0, 0, 1}.Multiply(srcT)
+	if _, err := res.Inverse(); err != nil {
+		return Matrix{}, errors.New("Degenerate correspondences")
+	}
+	return res, nil
+}
+
+// Similarity estimates the rotation, uniform scale and translation mapping
+// src to dst in least squares sense from at least 2 correspondences.
+// Reflections are not modelled.
+func Similarity(src, dst []Point) (Matrix, error) {
+	if err := checkCorrespondences(src, dst, 2); err != nil {
+		return Matrix{}, err
+	}
+	srcMean := mean(src)
+	dstMean := mean(dst)
+
+	// With centred points x' = a*x - b*y and y' = b*x + a*y.
+	var dot, cross, norm float64
+	for i := range src {
+		x, y := src[i].X-srcMean.X, src[i].Y-srcMean.Y
+		u, v := dst[i].X-dstMean.X, dst[i].Y-dstMean.Y
+		dot += x*u + y*v
+		cross += x*v - y*u
+		norm += x*x + y*y
+	}
+	if norm < 1e-12 {
+		return Matrix{}, errors.New("Degenerate correspondences")
+	}
+	a := dot / norm
+	b := cross / norm
+	if a == 0 && b == 0 {
+		return Matrix{}, errors.New("Degenerate correspondences")
+	}
+	return Matrix{
+		a, -b, dstMean.X - a*srcMean.X + b*srcMean.Y,
+		b, a, dstMean.Y - b*srcMean.X - a*srcMean.Y,
+		0, 0, 1,
+	}, nil
+}
+
+func checkCorrespondences(src, dst []Point, min int) error {
+	if len(src) != len(dst) {
+		return errors.New("Number of source and destination points differ")
+	}
+	if len(src) < min {
+		return errors.New("Not enough correspondences")
+	}
+	return nil
+}
+
+func mean(points []Point) Point {
+	var res Point
+	for _, p := range points {
+		res.X += p.X
+		res.Y += p.Y
+	}
+	res.X /= float64(len(points))
+	res.Y /= float64(len(points))
+	return res
+}
+
+// normalizePoints moves the centroid of points to the origin and scales them
+// to a mean distance of sqrt(2) from it, which keeps the DLT well
+// conditioned. It returns the normalized points and the transform applied.
+func normalizePoints(points []Point) ([]Point, Matrix) {
+	c := mean(points)
+	dist := 0.0
+	for _, p := range points {
+		dist += math.Hypot(p.X-c.X, p.Y-c.Y)
+	}
+	dist /= float64(len(points))
+	scale := 1.0
+	if dist > 0 {
+		scale = math.Sqrt2 / dist
+	}
+
+	t := Matrix{scale, 0, -scale * c.X, 0, scale, -scale * c.Y, 0, 0, 1}
+	res := make([]Point, len(points))
+	for i, p := range points {
+		res[i] = Point{X: scale * (p.X - c.X), Y: scale * (p.Y - c.Y)}
+	}
+	return res, t
+}
@@ -0,0 +1,296 @@
+package geometry
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+)
+
+type Model int
+
+const (
+	ModelHomography Model = iota
+	ModelAffine
+	ModelSimilarity
+)
+
+// MethodRANSAC keeps the model with the most inliers, MethodMSAC the one with
+// the smallest sum of squared errors truncated at the threshold. MethodLORANSAC
+// scores like MethodMSAC and refits every new best model on its inliers until
+// that stops improving it.
+type Method int
+
+const (
+	MethodRANSAC Method = iota
+	MethodMSAC
+	MethodLORANSAC
+)
+
+// RansacOptions configures Estimate. Threshold is the maximum reprojection
+// error of an inlier in pixels. The number of iterations adapts to the
+// inlier ratio found so far to reach Confidence, but never exceeds
+// MaxIterations. Seed makes the sampling reproducible. LocalIterations bounds
+// the refits of MethodLORANSAC.
+type RansacOptions struct {
+	Model           Model
+	Method          Method
+	Threshold       float64
+	Confidence      float64
+	MaxIterations   int
+	Seed            int64
+	LocalIterations int
+}
+
+// Result is the estimated transform from the source to the destination
+// points. Inliers[i] tells whether correspondence i is consistent with it.
+type Result struct {
+	Transform   Matrix
+	Inliers     []bool
+	InlierCount int
+	Iterations  int
+}
+
+func DefaultRansacOptions() RansacOptions {
+	return RansacOptions{
+		Model:           ModelHomography,
+		Method:          MethodRANSAC,
+		Threshold:       3,
+		Confidence:      0.995,
+		MaxIterations:   2000,
+		Seed:            1,
+		LocalIterations: 10,
+	}
+}
+
+func (o RansacOptions) validate() error {
+	switch o.Model {
+	case ModelHomography, ModelAffine, ModelSimilarity:
+	default:
+		return errors.New("Unknown model")
+	}
+	switch o.Method {
+	case MethodRANSAC, MethodMSAC, MethodLORANSAC:
+	default:
+		return errors.New("Unknown method")
+	}
+	if o.Threshold <= 0 {
+		return errors.New("Threshold must be bigger then 0")
+	}
+	if o.Confidence <= 0 || o.Confidence >= 1 {
+		return errors.New("Confidence must be between 0 and 1")
+	}
+	if o.MaxIterations < 1 {
+		return errors.New("Number of iterations must be at least 1")
+	}
+	if o.LocalIterations < 0 {
+		return errors.New("Number of local iterations must not be negative")
+	}
+	return nil
+}
+
+func (m Model) sampleSize() int {
+	switch m {
+	case ModelAffine:
+		return 3
+	case ModelSimilarity:
+		return 2
+	default:
+		return 4
+	}
+}
+
+func (m Model) fit(src, dst []Point) (Matrix, error) {
+	switch m {
+	case ModelAffine:
+		return Affine(src, dst)
+	case ModelSimilarity:
+		return Similarity(src, dst)
+	default:
+		return Homography(src, dst)
+	}
+}
+
+// Estimate robustly fits the configured model to the correspondences
+// src[i] -> dst[i], most of which may be wrong.
+func Estimate(src, dst []Point, opts RansacOptions) (Result, error) {
+	if err := opts.validate(); err != nil {
+		return Result{}, err
+	}
+	size := opts.Model.sampleSize()
+	if err := checkCorrespondences(src, dst, size); err != nil {
+		return Result{}, err
+	}
+
+	e := estimator{src: src, dst: dst, opts: opts, threshold: opts.Threshold * opts.Threshold}
+	random := rand.New(rand.NewSource(opts.Seed))
+	perm := make([]int, len(src))
+	for i := range perm {
+		perm[i] = i
+	}
+	sampleSrc := make([]Point, size)
+	sampleDst := make([]Point, size)
+
+	best := Matrix{}
+	bestCost := math.Inf(1)
+	bestCount := 0
+	limit := opts.MaxIterations
+	iterations := 0
+	for ; iterations < limit; iterations++ {
+		// Partial Fisher-Yates shuffle draws size distinct correspondences.
+		for i := 0; i < size; i++ {
+			j := i + random.Intn(len(perm)-i)
+			perm[i], perm[j] = perm[j], perm[i]
+			sampleSrc[i] = src[perm[i]]
+			sampleDst[i] = dst[perm[i]]
+		}
+		if degenerate(sampleSrc) || degenerate(sampleDst) {
+			continue
+		}
+		model, err := opts.Model.fit(sampleSrc, sampleDst)
+		if err != nil {
+			continue
+		}
+
+		cost, count := e.score(model)
+		if cost >= bestCost {
+			continue
+		}
+		if opts.Method == MethodLORANSAC {
+			model, cost, count = e.refine(model, cost, count, opts.LocalIterations)
+		}
+		best, bestCost, bestCount = model, cost, count
+		if n := adaptiveIterations(float64(count)/float64(len(src)), size, opts.Confidence); n < limit {
+			limit = n
+		}
+	}
+	if math.IsInf(bestCost, 1) || bestCount < size {
+		return Result{}, errors.New("No consistent model found")
+	}
+
+	// A final least squares fit on all inliers is less sensitive to the noise
+	// of the minimal sample. It is kept unless it loses inliers, even when
+	// the RANSAC count does not improve.
+	var inlierSrc, inlierDst []Point
+	for i := range src {
+		if e.error(best, i) <= e.threshold {
+			inlierSrc = append(inlierSrc, src[i])
+			inlierDst = append(inlierDst, dst[i])
+		}
+	}
+	if refit, err := opts.Model.fit(inlierSrc, inlierDst); err == nil {
+		if _, count := e.score(refit); count >= bestCount {
+			best = refit
+		}
+	}
+
+	res := Result{Transform: best, Inliers: make([]bool, len(src)), Iterations: iterations}
+	for i := range src {
+		if e.error(best, i) <= e.threshold {
+			res.Inliers[i] = true
+			res.InlierCount++
+		}
+	}
+	return res, nil
+}
+
+type estimator struct {
+	src       []Point
+	dst       []Point
+	opts      RansacOptions
+	threshold float64
+}
+
+// error is the squared distance between dst[i] and src[i] mapped by m.
+func (e *estimator) error(m Matrix, i int) float64 {
+	p := m.Apply(e.src[i])
+	dx := p.X - e.dst[i].X
+	dy := p.Y - e.dst[i].Y
+	d := dx*dx + dy*dy
+	if math.IsNaN(d) {
+		return math.Inf(1)
+	}
+	return d
+}
+
+// score returns the cost of m under the configured method, lower is better,
+// and its number of inliers.
+func (e *estimator) score(m Matrix) (float64, int) {
+	cost := 0.0
+	count := 0
+	for i := range e.src {
+		d := e.error(m, i)
+		if d <= e.threshold {
+			count++
+			cost += d
+		} else {
+			cost += e.threshold
+		}
+	}
+	if e.opts.Method == MethodRANSAC {
+		return float64(len(e.src) - count), count
+	}
+	return cost, count
+}
+
+// refine refits m on its inliers up to iterations times and keeps the fits
+// that lower the cost.
+func (e *estimator) refine(m Matrix, cost float64, count int, iterations int) (Matrix, float64, int) {
+	for it := 0; it < iterations; it++ {
+		var src, dst []Point
+		for i := range e.src {
+			if e.error(m, i) <= e.threshold {
+				src = append(src, e.src[i])
+				dst = append(dst, e.dst[i])
+			}
+		}
+		refit, err := e.opts.Model.fit(src, dst)
+		if err != nil {
+			break
+		}
+		refitCost, refitCount := e.score(refit)
+		if refitCost > cost || (refitCost == cost && refitCount <= count) {
+			break
+		}
+		m, cost, count = refit, refitCost, refitCount
+	}
+	return m, cost, count
+}
+
+// adaptiveIterations is the number of samples needed to draw one without
+// outliers with the given confidence when inlierRatio of the data are
+// inliers.
+func adaptiveIterations(inlierRatio float64, size int, confidence float64) int {
+	good := math.Pow(inlierRatio, float64(size))
+	if good >= 1 {
+		return 1
+	}
+	if good <= 0 {
+		return math.MaxInt32
+	}
+	n := math.Log(1-confidence) / math.Log(1-good)
+	if n > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return int(math.Ceil(n))
+}
+
+// degenerate reports whether the sample has coincident points or three
+// collinear points, which do not determine a transform.
+func degenerate(points []Point) bool {
+	const eps = 1e-6
+	for i := range points {
+		for j := i + 1; j < len(points); j++ {
+			if math.Hypot(points[i].X-points[j].X, points[i].Y-points[j].Y) < eps {
+				return true
+			}
+			for k := j + 1; k < len(points); k++ {
+				area := (points[j].X-points[i].X)*(points[k].Y-points[i].Y) -
+					(points[j].Y-points[i].Y)*(points[k].X-points[i].X)
+				if math.Abs(area) < eps {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
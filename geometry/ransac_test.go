@@ -0,0 +1,123 @@
+package geometry
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// correspondences maps random points by m, adds noise, and replaces every
+// third destination by a random point. outlier[i] marks the replaced ones.
+func correspondences(m Matrix, n int, seed int64) (src, dst []Point, outlier []bool) {
+	r := rand.New(rand.NewSource(seed))
+	for i := 0; i < n; i++ {
+		p := Point{X: 500 * r.Float64(), Y: 400 * r.Float64()}
+		q := m.Apply(p)
+		if i%3 == 0 {
+			q = Point{X: 600 * r.Float64(), Y: 500 * r.Float64()}
+		} else {
+			q.X += 0.3 * r.NormFloat64()
+			q.Y += 0.3 * r.NormFloat64()
+		}
+		src = append(src, p)
+		dst = append(dst, q)
+		outlier = append(outlier, i%3 == 0)
+	}
+	return src, dst, outlier
+}
+
+func TestEstimate(t *testing.T) {
+	models := []struct {
+		model     Model
+		transform Matrix
+	}{
+		{ModelHomography, Matrix{1.1, 0.05, 20, -0.03, 0.95, -10, 1e-4, -2e-4, 1}},
+		{ModelAffine, Matrix{0.9, 0.2, 30, -0.1, 1.2, -5, 0, 0, 1}},
+		{ModelSimilarity, Matrix{0.8 * math.Cos(0.3), -0.8 * math.Sin(0.3), 12, 0.8 * math.Sin(0.3), 0.8 * math.Cos(0.3), 40, 0, 0, 1}},
+	}
+	corners := []Point{{X: 0, Y: 0}, {X: 500, Y: 0}, {X: 0, Y: 400}, {X: 500, Y: 400}, {X: 250, Y: 200}}
+	for _, m := range models {
+		src, dst, outlier := correspondences(m.transform, 240, int64(m.model)+1)
+		for _, method := range []Method{MethodRANSAC, MethodMSAC, MethodLORANSAC} {
+			opts := DefaultRansacOptions()
+			opts.Model = m.model
+			opts.Method = method
+			res, err := Estimate(src, dst, opts)
+			if err != nil {
+				t.Fatalf("model %d, method %d: %v", m.model, method, err)
+			}
+			for _, p := range corners {
+				got, want := res.Transform.Apply(p), m.transform.Apply(p)
+				if d := math.Hypot(got.X-want.X, got.Y-want.Y); d > 1 {
+					t.Errorf("model %d, method %d: %v maps %g pixels away", m.model, method, p, d)
+				}
+			}
+			missed := 0
+			for i := range src {
+				if !outlier[i] && !res.Inliers[i] {
+					missed++
+				}
+			}
+			if missed > 4 || res.InlierCount < 156 {
+				t.Errorf("model %d, method %d: %d inliers, %d true inliers missed", m.model, method, res.InlierCount, missed)
+			}
+		}
+	}
+}
+
+func TestEstimateDeterministic(t *testing.T) {
+	src, dst, _ := correspondences(Matrix{1, 0.1, 5, -0.1, 1, 3, 1e-4, 0, 1}, 150, 9)
+	for _, method := range []Method{MethodRANSAC, MethodMSAC, MethodLORANSAC} {
+		opts := DefaultRansacOptions()
+		opts.Method = method
+		opts.Seed = 42
+		first, err := Estimate(src, dst, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := Estimate(src, dst, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(first, second) {
+			t.Errorf("method %d: same seed gave different results", method)
+		}
+	}
+}
+
+func TestDegenerate(t *testing.T) {
+	collinear := []Point{{X: 0, Y: 0}, {X: 1, Y: 1}, {X: 5, Y: 5}, {X: 2, Y: 7}}
+	coincident := []Point{{X: 3, Y: 4}, {X: 0, Y: 9}, {X: 3, Y: 4}, {X: 8, Y: 1}}
+	general := []Point{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 0, Y: 10}, {X: 7, Y: 9}}
+	if !degenerate(collinear) || !degenerate(coincident) {
+		t.Error("degenerate sample not detected")
+	}
+	if degenerate(general) {
+		t.Error("general sample reported as degenerate")
+	}
+
+	// Points on one line determine none of the models.
+	var src, dst []Point
+	for i := 0; i < 30; i++ {
+		p := Point{X: float64(i), Y: 2*float64(i) + 1}
+		src = append(src, p)
+		dst = append(dst, Point{X: p.X + 3, Y: p.Y - 2})
+	}
+	if _, err := Homography(src, dst); err == nil {
+		t.Error("homography fitted to collinear points")
+	}
+	if _, err := Affine(src, dst); err == nil {
+		t.Error("affine transform fitted to collinear points")
+	}
+	for _, model := range []Model{ModelHomography, ModelAffine} {
+		opts := DefaultRansacOptions()
+		opts.Model = model
+		if _, err := Estimate(src, dst, opts); err == nil {
+			t.Errorf("model %d estimated from collinear points", model)
+		}
+	}
+	if _, err := Estimate(src[:3], dst[:3], DefaultRansacOptions()); err == nil {
+		t.Error("homography estimated from 3 correspondences")
+	}
+}
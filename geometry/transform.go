@@ -0,0 +1,94 @@
+package geometry
+
+import (
+	"errors"
+	"math"
+
+	"github.com/alidadar7676/ComputerVision/matching"
+	"github.com/alidadar7676/ComputerVision/sift"
+)
+
+type Point struct {
+	X float64
+	Y float64
+}
+
+// Matrix is a 3x3 transform of homogeneous 2D points in row-major order.
+// Affine and similarity transforms have 0, 0, 1 as their last row.
+type Matrix [9]float64
+
+func Identity() Matrix {
+	return Matrix{1, 0, 0, 0, 1, 0, 0, 0, 1}
+}
+
+func Translation(tx, ty float64) Matrix {
+	return Matrix{1, 0, tx, 0, 1, ty, 0, 0, 1}
+}
+
+// Apply maps p through m. Points mapped to infinity have infinite or NaN
+// coordinates.
+func (m Matrix) Apply(p Point) Point {
+	w := m[6]*p.X + m[7]*p.Y + m[8]
+	return Point{
+		X: (m[0]*p.X + m[1]*p.Y + m[2]) / w,
+		Y: (m[3]*p.X + m[4]*p.Y + m[5]) / w,
+	}
+}
+
+// Multiply returns m * n, the transform that applies n first and then m.
+func (m Matrix) Multiply(n Matrix) Matrix {
+	var res Matrix
+	for r := 0; r < 3; r++ {
+		for c := 0; c < 3; c++ {
+			for k := 0; k < 3; k++ {
+				res[3*r+c] += m[3*r+k] * n[3*k+c]
+			}
+		}
+	}
+	return res
+}
+
+func (m Matrix) Determinant() float64 {
+	return m[0]*(m[4]*m[8]-m[5]*m[7]) - m[1]*(m[3]*m[8]-m[5]*m[6]) + m[2]*(m[3]*m[7]-m[4]*m[6])
+}
+
+func (m Matrix) Inverse() (Matrix, error) {
+	det := m.Determinant()
+	if det == 0 || math.IsNaN(det) || math.IsInf(det, 0) {
+		return Matrix{}, errors.New("Matrix is singular")
+	}
+	inv := Matrix{
+		m[4]*m[8] - m[5]*m[7], m[2]*m[7] - m[1]*m[8], m[1]*m[5] - m[2]*m[4],
+		m[5]*m[6] - m[3]*m[8], m[0]*m[8] - m[2]*m[6], m[2]*m[3] - m[0]*m[5],
+		m[3]*m[7] - m[4]*m[6], m[1]*m[6] - m[0]*m[7], m[0]*m[4] - m[1]*m[3],
+	}
+	for i := range inv {
+		inv[i] /= det
+	}
+	return inv, nil
+}
+
+// normalized scales m so its last element is 1 when that is possible.
+func (m Matrix) normalized() Matrix {
+	if m[8] == 0 {
+		return m
+	}
+	s := m[8]
+	for i := range m {
+		m[i] /= s
+	}
+	return m
+}
+
+// MatchedPoints returns the locations of the matched keypoints, query
+// keypoints in src and train keypoints in dst, ready for estimating the
+// transform from the query image to the train image.
+func MatchedPoints(query, train []sift.KeyPoint, matches []matching.Match) ([]Point, []Point) {
+	src := make([]Point, len(matches))
+	dst := make([]Point, len(matches))
+	for i, m := range matches {
+		src[i] = Point{X: query[m.QueryIndex].X, Y: query[m.QueryIndex].Y}
+		dst[i] = Point{X: train[m.TrainIndex].X, Y: train[m.TrainIndex].Y}
+	}
+	return src, dst
+}
@@ -0,0 +1,49 @@
+package gradient
+
+import (
+	"errors"
+	"math"
+
+	"github.com/alidadar7676/ComputerVision/floatImage"
+)
+
+// DiZenzo computes the color gradient of img as the largest eigenvalue of the
+// structure tensor summed over the color channels. The tensor is averaged over
+// the channels, so a gray image replicated in every channel yields the same
+// magnitude as GradientAndOrientation. The alpha channel (the fourth channel)
+// is ignored. The channel derivatives use Sobel kernels of the given aperture.
+func DiZenzo(img *floatImage.Multi, aperture int) (*floatImage.Gray, *floatImage.Gray, error) {
+	channels := img.Channels
+	if channels > 3 {
+		channels = 3
+	}
+	if channels == 0 {
+		return nil, nil, errors.New("Image has no channel")
+	}
+
+	gxx := floatImage.NewGray(img.Rect)
+	gyy := floatImage.NewGray(img.Rect)
+	gxy := floatImage.NewGray(img.Rect)
+	for c := 0; c < channels; c++ {
+		channel := img.Channel(c)
+		px, py, err := Sobel(channel, aperture)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := range gxx.Pix {
+			gxx.Pix[i] += px.Pix[i] * px.Pix[i] / float64(channels)
+			gyy.Pix[i] += py.Pix[i] * py.Pix[i] / float64(channels)
+			gxy.Pix[i] += px.Pix[i] * py.Pix[i] / float64(channels)
+		}
+	}
+
+	g := floatImage.NewGray(img.Rect)
+	theta := floatImage.NewGray(img.Rect)
+	for i := range g.Pix {
+		diff := gxx.Pix[i] - gyy.Pix[i]
+		lambda := 0.5 * (gxx.Pix[i] + gyy.Pix[i] + math.Sqrt(diff*diff+4*gxy.Pix[i]*gxy.Pix[i]))
+		g.Pix[i] = math.Sqrt(lambda)
+		theta.Pix[i] = 0.5 * math.Atan2(2*gxy.Pix[i], diff)
+	}
+	return g, theta, nil
+}
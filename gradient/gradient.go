@@ -1,10 +1,12 @@
 package gradient
 
 import (
-	"image"
 	"math"
 
 	"github.com/alidadar7676/ComputerVision/convolution"
+	"github.com/alidadar7676/ComputerVision/floatImage"
+	"github.com/alidadar7676/ComputerVision/padding"
+	"github.com/alidadar7676/ComputerVision/parallel"
 )
 
 var horizontalKernel = convolution.Kernel{Content: [][]float64{
@@ -19,8 +21,8 @@ var verticalKernel = convolution.Kernel{Content: [][]float64{
 	{1, 2, 1},
 }, Width: 3, Height: 3}
 
-func Horizontal(gray *image.Gray) ([][]float64, error) {
-	if mat, err := convolution.ConvolveGray(gray, &horizontalKernel); err != nil {
+func Horizontal(gray *floatImage.Gray) (*floatImage.Gray, error) {
+	if mat, err := convolution.Convolve(gray, &horizontalKernel, padding.BorderReplicate); err != nil {
 		return nil, err
 	} else {
 		return mat, nil
@@ -28,27 +30,33 @@ func Horizontal(gray *image.Gray) ([][]float64, error) {
 
 }
 
-func Vertical(gray *image.Gray) ([][]float64, error) {
-	if mat, err := convolution.ConvolveGray(gray, &verticalKernel); err != nil {
+func Vertical(gray *floatImage.Gray) (*floatImage.Gray, error) {
+	if mat, err := convolution.Convolve(gray, &verticalKernel, padding.BorderReplicate); err != nil {
 		return nil, err
 	} else {
 		return mat, nil
 	}
 }
 
-func GradientAndOrientation(bound image.Point, vertical, horizontal [][]float64) ([][]float64, [][]float64) {
-	size := bound
-	theta := make([][]float64, size.X)
-	g := make([][]float64, size.X)
-	for x := 0; x < size.X; x++ {
-		theta[x] = make([]float64, size.Y)
-		g[x] = make([]float64, size.Y)
-		for y := 0; y < size.Y; y++ {
-			px := vertical[x][y]   //float64(vertical.GrayAt(x, y).y)
-			py := horizontal[x][y] //float64(horizontal.GrayAt(x, y).y)
-			g[x][y] = math.Hypot(px, py)
-			theta[x][y] = math.Atan2(py, px) //math.Atan2(float64(vertical.GrayAt(x, y).y), float64(horizontal.GrayAt(x, y).y))
-		}
-	}
+func GradientAndOrientation(vertical, horizontal *floatImage.Gray) (*floatImage.Gray, *floatImage.Gray) {
+	rect := vertical.Rect
+	theta := floatImage.NewGray(rect)
+	g := floatImage.NewGray(rect)
+	parallel.ForEachPixel(rect, func(x, y int) {
+		px := vertical.FloatAt(x, y)
+		py := horizontal.FloatAt(x, y)
+		g.SetFloat(x, y, math.Hypot(px, py))
+		theta.SetFloat(x, y, math.Atan2(py, px))
+	})
 	return g, theta
 }
+
+// L1Magnitude returns |vertical| + |horizontal|, the cheaper alternative to
+// the Euclidean magnitude of GradientAndOrientation.
+func L1Magnitude(vertical, horizontal *floatImage.Gray) *floatImage.Gray {
+	g := floatImage.NewGray(vertical.Rect)
+	parallel.ForEachPixel(vertical.Rect, func(x, y int) {
+		g.SetFloat(x, y, math.Abs(vertical.FloatAt(x, y))+math.Abs(horizontal.FloatAt(x, y)))
+	})
+	return g
+}
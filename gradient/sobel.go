@@ -0,0 +1,65 @@
+package gradient
+
+import (
+	"errors"
+
+	"github.com/alidadar7676/ComputerVision/convolution"
+	"github.com/alidadar7676/ComputerVision/floatImage"
+	"github.com/alidadar7676/ComputerVision/padding"
+)
+
+// SobelKernels returns the d/dx and d/dy Sobel kernels for an aperture of 3,
+// 5 or 7 pixels. The 3 pixel kernels are the ones Vertical and Horizontal use.
+func SobelKernels(aperture int) (*convolution.Kernel, *convolution.Kernel, error) {
+	if aperture != 3 && aperture != 5 && aperture != 7 {
+		return nil, nil, errors.New("Aperture size must be 3, 5 or 7")
+	}
+	smooth := binomial(aperture)
+	derivative := convolve1D(binomial(aperture-2), []float64{-1, 0, 1})
+
+	dx, err := convolution.NewSeparableKernel(derivative, smooth)
+	if err != nil {
+		return nil, nil, err
+	}
+	dy, err := convolution.NewSeparableKernel(smooth, derivative)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dx, dy, nil
+}
+
+// Sobel returns the derivatives along x and y, in the same convention as
+// Vertical and Horizontal respectively.
+func Sobel(img *floatImage.Gray, aperture int) (*floatImage.Gray, *floatImage.Gray, error) {
+	kx, ky, err := SobelKernels(aperture)
+	if err != nil {
+		return nil, nil, err
+	}
+	dx, err := convolution.Convolve(img, kx, padding.BorderReplicate)
+	if err != nil {
+		return nil, nil, err
+	}
+	dy, err := convolution.Convolve(img, ky, padding.BorderReplicate)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dx, dy, nil
+}
+
+func binomial(n int) []float64 {
+	row := []float64{1}
+	for i := 1; i < n; i++ {
+		row = convolve1D(row, []float64{1, 1})
+	}
+	return row
+}
+
+func convolve1D(a, b []float64) []float64 {
+	res := make([]float64, len(a)+len(b)-1)
+	for i := range a {
+		for j := range b {
+			res[i+j] += a[i] * b[j]
+		}
+	}
+	return res
+}
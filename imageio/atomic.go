@@ -0,0 +1,61 @@
+package imageio
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+)
+
+// AtomicFile is a buffered file written next to its destination and moved
+// over it by Commit, so a failed or interrupted write never leaves a partial
+// file at the destination. Discard drops the file; it does nothing after a
+// Commit, so it can be deferred.
+type AtomicFile struct {
+	*bufio.Writer
+	file *os.File
+	path string
+	done bool
+}
+
+func CreateAtomic(path string) (*AtomicFile, error) {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return nil, err
+	}
+	return &AtomicFile{Writer: bufio.NewWriter(file), file: file, path: path}, nil
+}
+
+// Commit flushes the file and renames it to its destination.
+func (f *AtomicFile) Commit() error {
+	if f.done {
+		return os.ErrClosed
+	}
+	if err := f.Flush(); err != nil {
+		f.Discard()
+		return err
+	}
+	if err := f.file.Chmod(0644); err != nil {
+		f.Discard()
+		return err
+	}
+	f.done = true
+	temp := f.file.Name()
+	if err := f.file.Close(); err != nil {
+		os.Remove(temp)
+		return err
+	}
+	if err := os.Rename(temp, f.path); err != nil {
+		os.Remove(temp)
+		return err
+	}
+	return nil
+}
+
+func (f *AtomicFile) Discard() {
+	if f.done {
+		return
+	}
+	f.done = true
+	f.file.Close()
+	os.Remove(f.file.Name())
+}
@@ -0,0 +1,115 @@
+package imageio
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+)
+
+type Format int
+
+// FormatPBM, FormatPGM and FormatPPM are the Netpbm bitmap, gray map and
+// pixel map formats, in their plain (P1-P3) or binary (P4-P6) variants.
+const (
+	FormatUnknown Format = iota
+	FormatJPEG
+	FormatPNG
+	FormatGIF
+	FormatBMP
+	FormatTIFF
+	FormatPBM
+	FormatPGM
+	FormatPPM
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatJPEG:
+		return "jpeg"
+	case FormatPNG:
+		return "png"
+	case FormatGIF:
+		return "gif"
+	case FormatBMP:
+		return "bmp"
+	case FormatTIFF:
+		return "tiff"
+	case FormatPBM:
+		return "pbm"
+	case FormatPGM:
+		return "pgm"
+	case FormatPPM:
+		return "ppm"
+	}
+	return "unknown"
+}
+
+// UnsupportedError reports an input or output format this package can not
+// handle. Format describes it as well as it is known, e.g. a file extension.
+type UnsupportedError struct {
+	Format string
+}
+
+func (e *UnsupportedError) Error() string {
+	return "Unsupported image format: " + e.Format
+}
+
+// headerSize is the number of bytes DetectFormat needs at most.
+const headerSize = 8
+
+// DetectFormat identifies the format from the first bytes of an image file.
+func DetectFormat(header []byte) Format {
+	switch {
+	case bytes.HasPrefix(header, []byte{0xFF, 0xD8, 0xFF}):
+		return FormatJPEG
+	case bytes.HasPrefix(header, []byte("\x89PNG\r\n\x1a\n")):
+		return FormatPNG
+	case bytes.HasPrefix(header, []byte("GIF87a")), bytes.HasPrefix(header, []byte("GIF89a")):
+		return FormatGIF
+	case bytes.HasPrefix(header, []byte("BM")):
+		return FormatBMP
+	case bytes.HasPrefix(header, []byte("II*\x00")), bytes.HasPrefix(header, []byte("MM\x00*")):
+		return FormatTIFF
+	case len(header) >= 3 && header[0] == 'P' && isSpace(header[2]):
+		switch header[1] {
+		case '1', '4':
+			return FormatPBM
+		case '2', '5':
+			return FormatPGM
+		case '3', '6':
+			return FormatPPM
+		}
+	}
+	return FormatUnknown
+}
+
+// FormatFromPath picks the format from the file extension.
+func FormatFromPath(path string) (Format, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".jpg", ".jpeg":
+		return FormatJPEG, nil
+	case ".png":
+		return FormatPNG, nil
+	case ".gif":
+		return FormatGIF, nil
+	case ".bmp":
+		return FormatBMP, nil
+	case ".tif", ".tiff":
+		return FormatTIFF, nil
+	case ".pbm":
+		return FormatPBM, nil
+	case ".pgm":
+		return FormatPGM, nil
+	case ".ppm", ".pnm":
+		return FormatPPM, nil
+	}
+	if ext == "" {
+		ext = path
+	}
+	return FormatUnknown, &UnsupportedError{Format: ext}
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\v' || b == '\f'
+}
@@ -0,0 +1,153 @@
+package imageio
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+)
+
+// EncodeOptions configures Encode. Quality is the JPEG quality from 1 to 100,
+// 0 selects jpeg.DefaultQuality. Plain writes the ASCII variants (P1-P3) of
+// the Netpbm formats instead of the binary ones.
+type EncodeOptions struct {
+	Quality int
+	Plain   bool
+}
+
+func DefaultEncodeOptions() EncodeOptions {
+	return EncodeOptions{Quality: jpeg.DefaultQuality}
+}
+
+// DecodeOptions configures DecodeWithOptions. MaxPixels is the largest
+// width*height accepted before the pixels are allocated, it protects against
+// small files whose headers claim huge images.
+type DecodeOptions struct {
+	MaxPixels int
+}
+
+func DefaultDecodeOptions() DecodeOptions {
+	return DecodeOptions{MaxPixels: 1 << 26}
+}
+
+// Decode reads an image in any supported format, detected from its first
+// bytes rather than trusted from a file name. 16 bit PNG, TIFF and Netpbm
+// images keep their depth.
+func Decode(r io.Reader) (image.Image, Format, error) {
+	return DecodeWithOptions(r, DefaultDecodeOptions())
+}
+
+// DecodeWithOptions is Decode with a configurable size limit.
+func DecodeWithOptions(r io.Reader, opts DecodeOptions) (image.Image, Format, error) {
+	if opts.MaxPixels <= 0 {
+		return nil, FormatUnknown, errors.New("MaxPixels must be bigger then 0")
+	}
+	br := bufio.NewReader(r)
+	header, err := br.Peek(headerSize)
+	if err != nil && err != io.EOF {
+		return nil, FormatUnknown, err
+	}
+
+	format := DetectFormat(header)
+	var decode func(io.Reader) (image.Image, error)
+	var config func(io.Reader) (image.Config, error)
+	switch format {
+	case FormatJPEG:
+		decode, config = jpeg.Decode, jpeg.DecodeConfig
+	case FormatPNG:
+		decode, config = png.Decode, png.DecodeConfig
+	case FormatGIF:
+		decode, config = gif.Decode, gif.DecodeConfig
+	case FormatBMP:
+		decode, config = bmp.Decode, bmp.DecodeConfig
+	case FormatTIFF:
+		decode, config = tiff.Decode, tiff.DecodeConfig
+	case FormatPBM, FormatPGM, FormatPPM:
+		img, err := decodeNetpbm(br, opts.MaxPixels)
+		if err != nil {
+			return nil, format, err
+		}
+		return img, format, nil
+	default:
+		return nil, FormatUnknown, &UnsupportedError{Format: "unknown"}
+	}
+
+	// The header is read once for the size check and replayed for decoding.
+	var seen bytes.Buffer
+	cfg, err := config(io.TeeReader(br, &seen))
+	if err != nil {
+		return nil, format, err
+	}
+	if cfg.Width > 0 && cfg.Height > 0 && cfg.Width > opts.MaxPixels/cfg.Height {
+		return nil, format, errors.New("Image is bigger then the maximum number of pixels")
+	}
+	img, err := decode(io.MultiReader(&seen, br))
+	if err != nil {
+		return nil, format, err
+	}
+	return img, format, nil
+}
+
+// Encode writes img in format. Formats that can not hold the image convert
+// it: PGM to gray, PBM to black and white at half intensity, GIF to a
+// paletted image.
+func Encode(w io.Writer, img image.Image, format Format, opts EncodeOptions) error {
+	switch format {
+	case FormatJPEG:
+		quality := opts.Quality
+		if quality == 0 {
+			quality = jpeg.DefaultQuality
+		}
+		if quality < 1 || quality > 100 {
+			return errors.New("JPEG quality must be between 1 and 100")
+		}
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	case FormatPNG:
+		return png.Encode(w, img)
+	case FormatGIF:
+		return gif.Encode(w, img, nil)
+	case FormatBMP:
+		return bmp.Encode(w, img)
+	case FormatTIFF:
+		return tiff.Encode(w, img, &tiff.Options{Compression: tiff.Deflate})
+	case FormatPBM, FormatPGM, FormatPPM:
+		return encodeNetpbm(w, img, format, opts.Plain)
+	}
+	return &UnsupportedError{Format: format.String()}
+}
+
+func ReadFile(path string) (image.Image, Format, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, FormatUnknown, err
+	}
+	defer file.Close()
+	return Decode(file)
+}
+
+// WriteFile encodes img in the format given by the extension of path. The
+// image goes through an AtomicFile, so a failure never leaves a partial file
+// behind.
+func WriteFile(path string, img image.Image, opts EncodeOptions) error {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+	file, err := CreateAtomic(path)
+	if err != nil {
+		return err
+	}
+	if err := Encode(file, img, format, opts); err != nil {
+		file.Discard()
+		return err
+	}
+	return file.Commit()
+}
@@ -0,0 +1,339 @@
+package imageio
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strconv"
+)
+
+// decodeNetpbm reads P1 to P6 images. Gray maps become *image.Gray, pixel
+// maps *image.RGBA, or *image.Gray16 and *image.RGBA64 when the maximum value
+// is above 255. Samples are scaled to the full range of the result. Images
+// with more than maxPixels pixels are rejected, and the raster is decoded row
+// by row so a header claiming a huge size can not allocate more memory than
+// the data that actually follows it.
+func decodeNetpbm(r *bufio.Reader, maxPixels int) (image.Image, error) {
+	magic := make([]byte, 2)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, err
+	}
+	kind := magic[1]
+	if magic[0] != 'P' || kind < '1' || kind > '6' {
+		return nil, &UnsupportedError{Format: string(magic)}
+	}
+
+	width, err := readHeaderInt(r)
+	if err != nil {
+		return nil, err
+	}
+	height, err := readHeaderInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("Invalid Netpbm image size")
+	}
+	if width > maxPixels/height {
+		return nil, errors.New("Netpbm image is bigger then the maximum number of pixels")
+	}
+	maxValue := 1
+	if kind != '1' && kind != '4' {
+		if maxValue, err = readHeaderInt(r); err != nil {
+			return nil, err
+		}
+		if maxValue < 1 || maxValue > 65535 {
+			return nil, errors.New("Netpbm maximum value must be between 1 and 65535")
+		}
+	}
+	rect := image.Rect(0, 0, width, height)
+
+	channels := 1
+	if kind == '3' || kind == '6' {
+		channels = 3
+	}
+	wide := maxValue > 255
+	full := 255
+	if wide {
+		full = 65535
+	}
+	// Each pixel of the result holds 1, 2, 4 or 8 bytes.
+	depth := 1
+	if channels == 3 {
+		depth = 4
+	}
+	if wide {
+		depth *= 2
+	}
+	stride := width * depth
+
+	var pix []byte
+	bits := make([]byte, (width+7)/8)
+	size := 1
+	if wide {
+		size = 2
+	}
+	raw := make([]byte, width*channels*size)
+	samples := make([]int, width*channels)
+	row := make([]byte, stride)
+	for y := 0; y < height; y++ {
+		switch kind {
+		case '1', '4':
+			if kind == '4' {
+				if _, err := io.ReadFull(r, bits); err != nil {
+					return nil, shortRow(err)
+				}
+			}
+			for x := 0; x < width; x++ {
+				black := false
+				if kind == '4' {
+					black = bits[x/8]&(0x80>>uint(x%8)) != 0
+				} else {
+					c, err := readPlainBit(r)
+					if err != nil {
+						return nil, err
+					}
+					black = c == '1'
+				}
+				row[x] = 0
+				if !black {
+					row[x] = 255
+				}
+			}
+			pix = append(pix, row...)
+			continue
+		case '2', '3':
+			for i := range samples {
+				if samples[i], err = readHeaderInt(r); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			if _, err := io.ReadFull(r, raw); err != nil {
+				return nil, shortRow(err)
+			}
+			for i := range samples {
+				if wide {
+					samples[i] = int(raw[2*i])<<8 | int(raw[2*i+1])
+				} else {
+					samples[i] = int(raw[i])
+				}
+			}
+		}
+
+		for x := 0; x < width; x++ {
+			p := row[x*depth : (x+1)*depth]
+			for c := 0; c < channels; c++ {
+				s := samples[x*channels+c]
+				if s > maxValue {
+					return nil, errors.New("Netpbm sample is bigger then the maximum value")
+				}
+				s = (s*full + maxValue/2) / maxValue
+				if wide {
+					p[2*c], p[2*c+1] = uint8(s>>8), uint8(s)
+				} else {
+					p[c] = uint8(s)
+				}
+			}
+			if channels == 3 {
+				if wide {
+					p[6], p[7] = 0xff, 0xff
+				} else {
+					p[3] = 0xff
+				}
+			}
+		}
+		pix = append(pix, row...)
+	}
+
+	switch {
+	case channels == 1 && wide:
+		return &image.Gray16{Pix: pix, Stride: stride, Rect: rect}, nil
+	case channels == 1:
+		return &image.Gray{Pix: pix, Stride: stride, Rect: rect}, nil
+	case wide:
+		return &image.RGBA64{Pix: pix, Stride: stride, Rect: rect}, nil
+	}
+	return &image.RGBA{Pix: pix, Stride: stride, Rect: rect}, nil
+}
+
+// shortRow reports a raster that ends in the middle of a row.
+func shortRow(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// readHeaderInt reads a decimal number, skipping whitespace and comments. It
+// consumes the single whitespace byte after the number, which separates the
+// header from a binary raster.
+func readHeaderInt(r *bufio.Reader) (int, error) {
+	c, err := skipSpace(r)
+	if err != nil {
+		return 0, err
+	}
+	var digits []byte
+	for {
+		if c < '0' || c > '9' {
+			return 0, errors.New("Invalid Netpbm header")
+		}
+		digits = append(digits, c)
+		if c, err = r.ReadByte(); err == io.EOF || (err == nil && isSpace(c)) {
+			break
+		} else if err != nil {
+			return 0, err
+		}
+		if len(digits) > 9 {
+			return 0, errors.New("Invalid Netpbm header")
+		}
+	}
+	return strconv.Atoi(string(digits))
+}
+
+// readPlainBit reads one pixel of a P1 image, where the digits do not need to
+// be separated.
+func readPlainBit(r *bufio.Reader) (byte, error) {
+	c, err := skipSpace(r)
+	if err != nil {
+		return 0, err
+	}
+	if c != '0' && c != '1' {
+		return 0, errors.New("Invalid PBM pixel")
+	}
+	return c, nil
+}
+
+// skipSpace returns the first byte that is neither whitespace nor part of a
+// comment.
+func skipSpace(r *bufio.Reader) (byte, error) {
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		if c == '#' {
+			if _, err := r.ReadBytes('\n'); err != nil && err != io.EOF {
+				return 0, err
+			}
+			continue
+		}
+		if !isSpace(c) {
+			return c, nil
+		}
+	}
+}
+
+// encodeNetpbm writes img as PBM, PGM or PPM. Gray16, RGBA64 and NRGBA64
+// images are written with 16 bit samples.
+func encodeNetpbm(w io.Writer, img image.Image, format Format, plain bool) error {
+	bw := bufio.NewWriter(w)
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+
+	var wide bool
+	switch img.(type) {
+	case *image.Gray16, *image.RGBA64, *image.NRGBA64:
+		wide = true
+	}
+	maxValue := 255
+	if wide {
+		maxValue = 65535
+	}
+
+	magic := map[Format]int{FormatPBM: 4, FormatPGM: 5, FormatPPM: 6}[format]
+	if plain {
+		magic -= 3
+	}
+	if format == FormatPBM {
+		fmt.Fprintf(bw, "P%d\n%d %d\n", magic, width, height)
+	} else {
+		fmt.Fprintf(bw, "P%d\n%d %d\n%d\n", magic, width, height, maxValue)
+	}
+
+	p := plainWriter{w: bw}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		switch format {
+		case FormatPBM:
+			row := make([]byte, (width+7)/8)
+			for x := 0; x < width; x++ {
+				black := color.GrayModel.Convert(img.At(b.Min.X+x, y)).(color.Gray).Y < 128
+				if plain {
+					bit := 0
+					if black {
+						bit = 1
+					}
+					p.write(bit)
+				} else if black {
+					row[x/8] |= 0x80 >> uint(x%8)
+				}
+			}
+			if !plain {
+				bw.Write(row)
+			}
+		case FormatPGM:
+			for x := b.Min.X; x < b.Max.X; x++ {
+				v := int(color.Gray16Model.Convert(img.At(x, y)).(color.Gray16).Y)
+				writeSample(bw, &p, v, wide, plain)
+			}
+		default:
+			for x := b.Min.X; x < b.Max.X; x++ {
+				r, g, bl, _ := img.At(x, y).RGBA()
+				for _, v := range []uint32{r, g, bl} {
+					writeSample(bw, &p, int(v), wide, plain)
+				}
+			}
+		}
+		if plain {
+			p.newline()
+		}
+	}
+	return bw.Flush()
+}
+
+// writeSample writes the 16 bit value v with the depth of the file.
+func writeSample(w *bufio.Writer, p *plainWriter, v int, wide bool, plain bool) {
+	if !wide {
+		v >>= 8
+	}
+	switch {
+	case plain:
+		p.write(v)
+	case wide:
+		w.WriteByte(byte(v >> 8))
+		w.WriteByte(byte(v))
+	default:
+		w.WriteByte(byte(v))
+	}
+}
+
+// plainWriter writes the numbers of the plain formats, breaking lines before
+// they exceed 70 characters as the format asks.
+type plainWriter struct {
+	w      *bufio.Writer
+	length int
+}
+
+func (p *plainWriter) write(v int) {
+	s := strconv.Itoa(v)
+	if p.length > 0 && p.length+1+len(s) > 70 {
+		p.newline()
+	}
+	if p.length > 0 {
+		p.w.WriteByte(' ')
+		p.length++
+	}
+	p.w.WriteString(s)
+	p.length += len(s)
+}
+
+func (p *plainWriter) newline() {
+	p.w.WriteByte('\n')
+	p.length = 0
+}
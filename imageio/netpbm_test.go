@@ -0,0 +1,119 @@
+package imageio
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNetpbmRoundTrip(t *testing.T) {
+	rect := image.Rect(0, 0, 13, 5)
+	bits := image.NewGray(rect)
+	gray := image.NewGray(rect)
+	gray16 := image.NewGray16(rect)
+	rgba := image.NewRGBA(rect)
+	rgba64 := image.NewRGBA64(rect)
+	for y := 0; y < rect.Dy(); y++ {
+		for x := 0; x < rect.Dx(); x++ {
+			if (x*y+x)%3 == 0 {
+				bits.SetGray(x, y, color.Gray{Y: 255})
+			}
+			gray.SetGray(x, y, color.Gray{Y: uint8(19*x + 7*y)})
+			gray16.SetGray16(x, y, color.Gray16{Y: uint16(4099*x + 257*y + 1)})
+			rgba.SetRGBA(x, y, color.RGBA{R: uint8(20 * x), G: uint8(50 * y), B: uint8(x * y), A: 255})
+			rgba64.SetRGBA64(x, y, color.RGBA64{R: uint16(5000 * x), G: uint16(300*y + 1), B: uint16(x*y + 258), A: 65535})
+		}
+	}
+	tests := []struct {
+		format Format
+		img    image.Image
+		magic  [2]string
+	}{
+		{FormatPBM, bits, [2]string{"P4", "P1"}},
+		{FormatPGM, gray, [2]string{"P5", "P2"}},
+		{FormatPGM, gray16, [2]string{"P5", "P2"}},
+		{FormatPPM, rgba, [2]string{"P6", "P3"}},
+		{FormatPPM, rgba64, [2]string{"P6", "P3"}},
+	}
+	for _, test := range tests {
+		for i, plain := range []bool{false, true} {
+			var buf bytes.Buffer
+			if err := Encode(&buf, test.img, test.format, EncodeOptions{Plain: plain}); err != nil {
+				t.Fatal(err)
+			}
+			if magic := buf.String()[:2]; magic != test.magic[i] {
+				t.Errorf("%T as %v: magic %s, want %s", test.img, test.format, magic, test.magic[i])
+			}
+			res, format, err := Decode(&buf)
+			if err != nil {
+				t.Fatalf("%T as %s: %v", test.img, test.magic[i], err)
+			}
+			if format != test.format {
+				t.Errorf("%T as %s: detected %v", test.img, test.magic[i], format)
+			}
+			if !reflect.DeepEqual(res, test.img) {
+				t.Errorf("%T as %s: decoded %T differs", test.img, test.magic[i], res)
+			}
+		}
+	}
+}
+
+func TestNetpbmHeaders(t *testing.T) {
+	tests := []struct {
+		data string
+		want image.Image
+	}{
+		{"P2\n# comment\n2 # width\n1\n15\n0 15\n", &image.Gray{Pix: []uint8{0, 255}, Stride: 2, Rect: image.Rect(0, 0, 2, 1)}},
+		{"P1 3 1 1 0#c\n1", &image.Gray{Pix: []uint8{0, 255, 0}, Stride: 3, Rect: image.Rect(0, 0, 3, 1)}},
+		{"P1\n3 1\n101", &image.Gray{Pix: []uint8{0, 255, 0}, Stride: 3, Rect: image.Rect(0, 0, 3, 1)}},
+		{"P5 2 1 1000\n\x00\x00\x03\xe8", &image.Gray16{Pix: []uint8{0, 0, 255, 255}, Stride: 4, Rect: image.Rect(0, 0, 2, 1)}},
+		{"P3 1 1 255 1 2 3", &image.RGBA{Pix: []uint8{1, 2, 3, 255}, Stride: 4, Rect: image.Rect(0, 0, 1, 1)}},
+	}
+	for _, test := range tests {
+		img, _, err := Decode(strings.NewReader(test.data))
+		if err != nil {
+			t.Errorf("%q: %v", test.data, err)
+			continue
+		}
+		if !reflect.DeepEqual(img, test.want) {
+			t.Errorf("%q: decoded %v, want %v", test.data, img, test.want)
+		}
+	}
+}
+
+// TestNetpbmMalformed checks that broken files give errors, not partial
+// images.
+func TestNetpbmMalformed(t *testing.T) {
+	for _, data := range []string{
+		"P2",
+		"P2 4",
+		"P2 4 x 255\n",
+		"P2 0 3 255\n",
+		"P2 2 1 0\n0 0",
+		"P2 2 1 70000\n0 0",
+		"P2 2 1 255\n1",
+		"P2 2 1 255\n1 300",
+		"P2 2 1 255\n1 -2",
+		"P5 4 2 255\nabcdefg",
+		"P5 2 2 65535\n\x00\x01\x00\x02\x00\x03\x00",
+		"P6 2 1 255\n\x01\x02\x03\x04\x05",
+		"P4 9 2\n\xff\xff\xff",
+		"P1 2 2\n1 0 2 1",
+		"P3 1 1 255 1 2",
+		"P5 99999999 99999999 255\n\x00",
+		"P2 1234567890123 1 255\n",
+	} {
+		img, _, err := Decode(strings.NewReader(data))
+		if err == nil || img != nil {
+			t.Errorf("%q: decoded %v with error %v", data, img, err)
+		}
+	}
+
+	_, _, err := DecodeWithOptions(strings.NewReader("P5 10 10 255\n"+strings.Repeat("a", 100)), DecodeOptions{MaxPixels: 99})
+	if err == nil {
+		t.Error("image above MaxPixels decoded")
+	}
+}
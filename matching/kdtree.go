@@ -0,0 +1,437 @@
+package matching
+
+import (
+	"container/heap"
+	"encoding/gob"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/alidadar7676/ComputerVision/parallel"
+	"github.com/alidadar7676/ComputerVision/sift"
+)
+
+const (
+	// varianceSamples is the number of points used to estimate the variance
+	// of every dimension when splitting a node.
+	varianceSamples = 100
+	// randomDimensions is the number of highest variance dimensions a split
+	// dimension is drawn from, which makes the trees of the forest differ.
+	randomDimensions = 5
+)
+
+// IndexOptions configures a randomized kd-forest. Trees is the number of
+// trees searched together, LeafSize the maximum number of descriptors in a
+// leaf and Seed makes the random split choices reproducible.
+type IndexOptions struct {
+	Trees    int
+	LeafSize int
+	Seed     int64
+}
+
+func DefaultIndexOptions() IndexOptions {
+	return IndexOptions{Trees: 4, LeafSize: 4, Seed: 1}
+}
+
+// Index is a FLANN style forest of randomized kd-trees over a fixed set of
+// descriptors for approximate L2 nearest neighbour search.
+type Index struct {
+	Descriptors [][]float64
+	Trees       []Tree
+
+	marks sync.Pool
+}
+
+// Tree is a kd-tree stored as a flat node list so it can be serialized.
+// Node 0 is the root. Leaves have Left == -1 and own Indices[Start:End].
+type Tree struct {
+	Nodes   []Node
+	Indices []int
+}
+
+type Node struct {
+	Dim   int
+	Split float64
+	Left  int
+	Right int
+	Start int
+	End   int
+}
+
+func NewIndex(train []sift.KeyPoint, opts IndexOptions) (*Index, error) {
+	return NewIndexDescriptors(Descriptors(train), opts)
+}
+
+func NewIndexDescriptors(train [][]float64, opts IndexOptions) (*Index, error) {
+	if opts.Trees < 1 {
+		return nil, errors.New("Number of trees must be at least 1")
+	}
+	if opts.LeafSize < 1 {
+		return nil, errors.New("Leaf size must be at least 1")
+	}
+	if len(train) == 0 {
+		return nil, errors.New("No descriptor to index")
+	}
+	if err := checkDimensions(train, nil); err != nil {
+		return nil, err
+	}
+
+	idx := &Index{Descriptors: train, Trees: make([]Tree, opts.Trees)}
+	// Every tree gets its own generator, seeded in order, so the trees can be
+	// built concurrently and still be reproducible.
+	random := rand.New(rand.NewSource(opts.Seed))
+	seeds := make([]int64, opts.Trees)
+	for t := range seeds {
+		seeds[t] = random.Int63()
+	}
+	parallel.Run(opts.Trees, func(t int) {
+		idx.Trees[t].Indices = make([]int, len(train))
+		for i := range train {
+			idx.Trees[t].Indices[i] = i
+		}
+		b := builder{data: train, tree: &idx.Trees[t], leafSize: opts.LeafSize, random: rand.New(rand.NewSource(seeds[t]))}
+		b.build(0, len(train))
+	})
+	return idx, nil
+}
+
+type builder struct {
+	data     [][]float64
+	tree     *Tree
+	leafSize int
+	random   *rand.Rand
+}
+
+func (b *builder) build(start, end int) int {
+	node := len(b.tree.Nodes)
+	b.tree.Nodes = append(b.tree.Nodes, Node{Left: -1, Right: -1, Start: start, End: end})
+	if end-start <= b.leafSize {
+		return node
+	}
+
+	dim, split := b.chooseSplit(start, end)
+	indices := b.tree.Indices
+	mid := start
+	for i := start; i < end; i++ {
+		if b.data[indices[i]][dim] < split {
+			indices[i], indices[mid] = indices[mid], indices[i]
+			mid++
+		}
+	}
+	if mid == start || mid == end {
+		// The mean of the sampled points missed the range. Split at the
+		// median instead, between two distinct values so every point left
+		// of the split stays below it, or stop at a leaf when all values
+		// are equal in this dimension.
+		sub := indices[start:end]
+		value := func(i int) float64 {
+			return b.data[sub[i]][dim]
+		}
+		sort.Slice(sub, func(i, j int) bool {
+			return value(i) < value(j)
+		})
+		median := value(len(sub) / 2)
+		cut := sort.Search(len(sub), func(i int) bool {
+			return value(i) >= median
+		})
+		if cut == 0 {
+			cut = sort.Search(len(sub), func(i int) bool {
+				return value(i) > median
+			})
+		}
+		if cut == len(sub) {
+			return node
+		}
+		mid, split = start+cut, value(cut)
+	}
+
+	left := b.build(start, mid)
+	right := b.build(mid, end)
+	b.tree.Nodes[node].Dim = dim
+	b.tree.Nodes[node].Split = split
+	b.tree.Nodes[node].Left = left
+	b.tree.Nodes[node].Right = right
+	return node
+}
+
+// chooseSplit picks one of the randomDimensions dimensions with the highest
+// variance and splits it at the mean.
+func (b *builder) chooseSplit(start, end int) (int, float64) {
+	count := end - start
+	if count > varianceSamples {
+		count = varianceSamples
+	}
+	dims := len(b.data[b.tree.Indices[start]])
+	mean := make([]float64, dims)
+	variance := make([]float64, dims)
+	for i := start; i < start+count; i++ {
+		for d, v := range b.data[b.tree.Indices[i]] {
+			mean[d] += v
+		}
+	}
+	for d := range mean {
+		mean[d] /= float64(count)
+	}
+	for i := start; i < start+count; i++ {
+		for d, v := range b.data[b.tree.Indices[i]] {
+			variance[d] += (v - mean[d]) * (v - mean[d])
+		}
+	}
+
+	order := make([]int, dims)
+	for d := range order {
+		order[d] = d
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return variance[order[i]] > variance[order[j]]
+	})
+	candidates := randomDimensions
+	if candidates > dims {
+		candidates = dims
+	}
+	dim := order[b.random.Intn(candidates)]
+	return dim, mean[dim]
+}
+
+// KNN returns the approximate k nearest neighbours of every query. checks
+// bounds the number of distance computations per query; 0 searches until the
+// result is exact.
+func (idx *Index) KNN(query []sift.KeyPoint, k int, checks int) ([][]Match, error) {
+	return idx.KNNDescriptors(Descriptors(query), k, checks)
+}
+
+func (idx *Index) KNNDescriptors(query [][]float64, k int, checks int) ([][]Match, error) {
+	if k < 1 {
+		return nil, errors.New("k must be at least 1")
+	}
+	if checks < 0 {
+		return nil, errors.New("Checks must not be negative")
+	}
+	if err := checkDimensions(query, idx.Descriptors[:1]); err != nil {
+		return nil, err
+	}
+
+	res := make([][]Match, len(query))
+	parallel.Run(len(query), func(q int) {
+		res[q] = idx.search(q, query[q], k, checks)
+	})
+	return res, nil
+}
+
+// Match is BruteForceMatch against the indexed descriptors. Only the L2
+// metric is supported and cross-checking is not.
+func (idx *Index) Match(query []sift.KeyPoint, opts Options, checks int) ([]Match, error) {
+	if opts.Metric != L2 {
+		return nil, errors.New("The index only supports the L2 metric")
+	}
+	if opts.CrossCheck {
+		return nil, errors.New("The index does not support cross-checking")
+	}
+	if opts.Ratio < 0 || opts.Ratio > 1 {
+		return nil, errors.New("Ratio must be between 0 and 1")
+	}
+	k := 1
+	if opts.Ratio > 0 {
+		k = 2
+	}
+	knn, err := idx.KNN(query, k, checks)
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]Match, 0, len(knn))
+	for _, neighbours := range knn {
+		if len(neighbours) == 0 {
+			continue
+		}
+		if opts.Ratio > 0 && len(neighbours) > 1 && neighbours[0].Distance >= opts.Ratio*neighbours[1].Distance {
+			continue
+		}
+		matches = append(matches, neighbours[0])
+	}
+	return matches, nil
+}
+
+type branch struct {
+	tree  int
+	node  int
+	bound float64
+}
+
+type branchHeap []branch
+
+func (h branchHeap) Len() int            { return len(h) }
+func (h branchHeap) Less(i, j int) bool  { return h[i].bound < h[j].bound }
+func (h branchHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *branchHeap) Push(x interface{}) { *h = append(*h, x.(branch)) }
+func (h *branchHeap) Pop() interface{} {
+	old := *h
+	b := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return b
+}
+
+// visited marks the descriptors already compared to the current query, so a
+// descriptor found in several trees is only counted once. Marks are stamped
+// with a generation number to avoid clearing them between queries.
+type visited struct {
+	marks      []uint32
+	generation uint32
+}
+
+func (idx *Index) search(q int, query []float64, k int, checks int) []Match {
+	v, _ := idx.marks.Get().(*visited)
+	if v == nil || len(v.marks) != len(idx.Descriptors) {
+		v = &visited{marks: make([]uint32, len(idx.Descriptors))}
+	}
+	v.generation++
+	if v.generation == 0 {
+		for i := range v.marks {
+			v.marks[i] = 0
+		}
+		v.generation = 1
+	}
+	defer idx.marks.Put(v)
+
+	// best holds squared distances until the end of the search.
+	best := make([]Match, 0, k+1)
+	branches := &branchHeap{}
+	for t := range idx.Trees {
+		heap.Push(branches, branch{tree: t, node: 0})
+	}
+
+	count := 0
+	for branches.Len() > 0 && (checks == 0 || count < checks) {
+		b := heap.Pop(branches).(branch)
+		if len(best) == k && b.bound >= best[k-1].Distance {
+			continue
+		}
+		tree := &idx.Trees[b.tree]
+		node := b.node
+		for tree.Nodes[node].Left != -1 {
+			n := tree.Nodes[node]
+			diff := query[n.Dim] - n.Split
+			near, far := n.Left, n.Right
+			if diff >= 0 {
+				near, far = n.Right, n.Left
+			}
+			heap.Push(branches, branch{tree: b.tree, node: far, bound: math.Max(b.bound, diff*diff)})
+			node = near
+		}
+
+		n := tree.Nodes[node]
+		for _, i := range tree.Indices[n.Start:n.End] {
+			if v.marks[i] == v.generation {
+				continue
+			}
+			v.marks[i] = v.generation
+			count++
+			d := squaredDistance(query, idx.Descriptors[i])
+			if len(best) == k && d >= best[k-1].Distance {
+				continue
+			}
+			best = insertSorted(best, Match{QueryIndex: q, TrainIndex: i, Distance: d}, k)
+		}
+	}
+
+	for i := range best {
+		best[i].Distance = math.Sqrt(best[i].Distance)
+	}
+	return best
+}
+
+func squaredDistance(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return sum
+}
+
+// Save writes the descriptors and the trees, so a reference set only has to
+// be indexed once.
+func (idx *Index) Save(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(struct {
+		Descriptors [][]float64
+		Trees       []Tree
+	}{idx.Descriptors, idx.Trees})
+}
+
+func LoadIndex(r io.Reader) (*Index, error) {
+	var data struct {
+		Descriptors [][]float64
+		Trees       []Tree
+	}
+	if err := gob.NewDecoder(r).Decode(&data); err != nil {
+		return nil, err
+	}
+	if len(data.Descriptors) == 0 || len(data.Trees) == 0 {
+		return nil, errors.New("Invalid index")
+	}
+	if err := checkDimensions(nil, data.Descriptors); err != nil {
+		return nil, err
+	}
+	for _, t := range data.Trees {
+		if err := t.validate(len(data.Descriptors), len(data.Descriptors[0])); err != nil {
+			return nil, err
+		}
+	}
+	return &Index{Descriptors: data.Descriptors, Trees: data.Trees}, nil
+}
+
+// validate checks a loaded tree, so a corrupt file fails to load instead of
+// making the search panic or loop. Indices must be a permutation of the
+// points and children must come after their parent, as build stores them.
+func (t Tree) validate(points, dims int) error {
+	invalid := errors.New("Invalid index tree")
+	if len(t.Nodes) == 0 || len(t.Indices) != points {
+		return invalid
+	}
+	seen := make([]bool, points)
+	for _, i := range t.Indices {
+		if i < 0 || i >= points || seen[i] {
+			return invalid
+		}
+		seen[i] = true
+	}
+	for i, n := range t.Nodes {
+		if n.Left == -1 {
+			if n.Start < 0 || n.Start > n.End || n.End > points {
+				return invalid
+			}
+			continue
+		}
+		if n.Left <= i || n.Left >= len(t.Nodes) || n.Right <= i || n.Right >= len(t.Nodes) {
+			return invalid
+		}
+		if n.Dim < 0 || n.Dim >= dims || math.IsNaN(n.Split) {
+			return invalid
+		}
+	}
+	return nil
+}
+
+func (idx *Index) SaveFile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := idx.Save(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func LoadIndexFile(path string) (*Index, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return LoadIndex(file)
+}
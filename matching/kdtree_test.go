@@ -0,0 +1,126 @@
+package matching
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func randomDescriptors(r *rand.Rand, n, dims int) [][]float64 {
+	res := make([][]float64, n)
+	for i := range res {
+		res[i] = make([]float64, dims)
+		for d := range res[i] {
+			res[i][d] = r.Float64()
+		}
+	}
+	return res
+}
+
+// checkExact compares an unbounded search (checks == 0) of the index with
+// brute force. Distances are compared because ties may pick other indices.
+func checkExact(t *testing.T, name string, train, query [][]float64, opts IndexOptions) {
+	idx, err := NewIndexDescriptors(train, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const k = 3
+	exact, err := KNNDescriptors(query, train, k, L2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := idx.KNNDescriptors(query, k, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for q := range query {
+		if len(res[q]) != len(exact[q]) {
+			t.Fatalf("%s: query %d found %d neighbours, want %d", name, q, len(res[q]), len(exact[q]))
+		}
+		for i := range exact[q] {
+			if math.Abs(res[q][i].Distance-exact[q][i].Distance) > 1e-12 {
+				t.Fatalf("%s: query %d neighbour %d at %g, want %g", name, q, i, res[q][i].Distance, exact[q][i].Distance)
+			}
+		}
+	}
+}
+
+func TestIndexExact(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	train := randomDescriptors(r, 2000, 16)
+	query := randomDescriptors(r, 100, 16)
+	checkExact(t, "uniform", train, query, DefaultIndexOptions())
+	checkExact(t, "one tree", train, query, IndexOptions{Trees: 1, LeafSize: 1, Seed: 7})
+
+	// The first points, all sampled to choose the splits, are equal and the
+	// rest takes few distinct values, so the mean of the samples separates
+	// nothing and many points share the split value.
+	degenerate := make([][]float64, 600)
+	for i := range degenerate {
+		degenerate[i] = make([]float64, 8)
+		if i >= 150 {
+			for d := range degenerate[i] {
+				degenerate[i][d] = float64(r.Intn(3))
+			}
+		}
+	}
+	for i := range query {
+		query[i] = query[i][:8]
+		for d := range query[i] {
+			query[i][d] *= 2
+		}
+	}
+	checkExact(t, "degenerate", degenerate, query, DefaultIndexOptions())
+}
+
+func TestIndexSaveLoad(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	train := randomDescriptors(r, 300, 8)
+	query := randomDescriptors(r, 20, 8)
+	idx, err := NewIndexDescriptors(train, DefaultIndexOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := idx.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	saved := buf.Bytes()
+	loaded, err := LoadIndex(bytes.NewReader(saved))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := idx.KNNDescriptors(query, 2, 32)
+	got, _ := loaded.KNNDescriptors(query, 2, 32)
+	for q := range want {
+		for i := range want[q] {
+			if got[q][i] != want[q][i] {
+				t.Fatalf("query %d: loaded index found %v, want %v", q, got[q][i], want[q][i])
+			}
+		}
+	}
+
+	corruptions := map[string]func(tree *Tree, descriptors [][]float64){
+		"index out of range": func(tree *Tree, _ [][]float64) { tree.Indices[3] = len(tree.Indices) },
+		"repeated index":     func(tree *Tree, _ [][]float64) { tree.Indices[3] = tree.Indices[4] },
+		"child cycle":        func(tree *Tree, _ [][]float64) { tree.Nodes[0].Left = 0 },
+		"split dimension":    func(tree *Tree, _ [][]float64) { tree.Nodes[0].Dim = 8 },
+		"leaf range":         func(tree *Tree, _ [][]float64) { tree.Nodes[len(tree.Nodes)-1].End = 1000 },
+		"descriptor size":    func(_ *Tree, descriptors [][]float64) { descriptors[5] = descriptors[5][:3] },
+	}
+	for name, corrupt := range corruptions {
+		corrupted, err := LoadIndex(bytes.NewReader(saved))
+		if err != nil {
+			t.Fatal(err)
+		}
+		corrupt(&corrupted.Trees[1], corrupted.Descriptors)
+		var buf bytes.Buffer
+		if err := corrupted.Save(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadIndex(&buf); err == nil {
+			t.Errorf("%s: corrupt index loaded", name)
+		}
+	}
+}
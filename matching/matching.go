@@ -0,0 +1,201 @@
+package matching
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/alidadar7676/ComputerVision/parallel"
+	"github.com/alidadar7676/ComputerVision/sift"
+)
+
+// Match pairs the descriptor QueryIndex of the query set with TrainIndex of
+// the train set.
+type Match struct {
+	QueryIndex int
+	TrainIndex int
+	Distance   float64
+}
+
+type Metric int
+
+const (
+	L2 Metric = iota
+	// Hamming treats every descriptor element as a byte of a binary
+	// descriptor and counts the differing bits.
+	Hamming
+)
+
+// Options configures BruteForceMatch. Ratio enables Lowe's ratio test when it is not
+// 0: a match is kept only if its distance is below Ratio times the distance
+// to the second nearest neighbour. CrossCheck keeps only mutual nearest
+// neighbours.
+type Options struct {
+	Metric     Metric
+	Ratio      float64
+	CrossCheck bool
+}
+
+func DefaultOptions() Options {
+	return Options{Metric: L2, Ratio: 0.8}
+}
+
+func Descriptors(keys []sift.KeyPoint) [][]float64 {
+	res := make([][]float64, len(keys))
+	for i, key := range keys {
+		res[i] = key.Feature
+	}
+	return res
+}
+
+// BruteForceMatch matches every query keypoint to its nearest train keypoint and
+// filters the matches as configured. The result is ordered by QueryIndex.
+func BruteForceMatch(query, train []sift.KeyPoint, opts Options) ([]Match, error) {
+	if opts.Ratio < 0 || opts.Ratio > 1 {
+		return nil, errors.New("Ratio must be between 0 and 1")
+	}
+	k := 1
+	if opts.Ratio > 0 {
+		k = 2
+	}
+	knn, err := KNN(query, train, k, opts.Metric)
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]Match, 0, len(knn))
+	for _, neighbours := range knn {
+		if len(neighbours) == 0 {
+			continue
+		}
+		if opts.Ratio > 0 && len(neighbours) > 1 && neighbours[0].Distance >= opts.Ratio*neighbours[1].Distance {
+			continue
+		}
+		matches = append(matches, neighbours[0])
+	}
+
+	if opts.CrossCheck {
+		reverse, err := KNN(train, query, 1, opts.Metric)
+		if err != nil {
+			return nil, err
+		}
+		matches = crossCheck(matches, reverse)
+	}
+	return matches, nil
+}
+
+// KNN returns the k nearest train descriptors of every query descriptor,
+// nearest first, by brute force. The queries are spread over the parallel
+// workers.
+func KNN(query, train []sift.KeyPoint, k int, metric Metric) ([][]Match, error) {
+	return KNNDescriptors(Descriptors(query), Descriptors(train), k, metric)
+}
+
+func KNNDescriptors(query, train [][]float64, k int, metric Metric) ([][]Match, error) {
+	if k < 1 {
+		return nil, errors.New("k must be at least 1")
+	}
+	distance, err := DistanceFunc(metric)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDimensions(query, train); err != nil {
+		return nil, err
+	}
+
+	res := make([][]Match, len(query))
+	parallel.Run(len(query), func(q int) {
+		best := make([]Match, 0, k+1)
+		for t := range train {
+			d := distance(query[q], train[t])
+			if len(best) == k && d >= best[k-1].Distance {
+				continue
+			}
+			best = insertSorted(best, Match{QueryIndex: q, TrainIndex: t, Distance: d}, k)
+		}
+		res[q] = best
+	})
+	return res, nil
+}
+
+func DistanceFunc(metric Metric) (func(a, b []float64) float64, error) {
+	switch metric {
+	case L2:
+		return L2Distance, nil
+	case Hamming:
+		return HammingDistance, nil
+	}
+	return nil, errors.New("Unknown metric")
+}
+
+func L2Distance(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+// HammingDistance counts the differing bits of descriptors holding one byte
+// per element. Elements are rounded and clamped to [0, 255] first, NaN counts
+// as 0, since converting other values to uint8 is implementation-defined.
+func HammingDistance(a, b []float64) float64 {
+	sum := 0
+	for i := range a {
+		sum += bits.OnesCount8(toByte(a[i]) ^ toByte(b[i]))
+	}
+	return float64(sum)
+}
+
+func toByte(value float64) uint8 {
+	if !(value > 0) {
+		return 0
+	}
+	if value >= 255 {
+		return 255
+	}
+	return uint8(math.Round(value))
+}
+
+// insertSorted inserts m into the ascending list and keeps at most k entries.
+// Equal distances keep the earlier train index first.
+func insertSorted(list []Match, m Match, k int) []Match {
+	i := sort.Search(len(list), func(i int) bool {
+		return list[i].Distance > m.Distance
+	})
+	list = append(list, Match{})
+	copy(list[i+1:], list[i:])
+	list[i] = m
+	if len(list) > k {
+		list = list[:k]
+	}
+	return list
+}
+
+func crossCheck(matches []Match, reverse [][]Match) []Match {
+	res := make([]Match, 0, len(matches))
+	for _, m := range matches {
+		if back := reverse[m.TrainIndex]; len(back) > 0 && back[0].TrainIndex == m.QueryIndex {
+			res = append(res, m)
+		}
+	}
+	return res
+}
+
+func checkDimensions(query, train [][]float64) error {
+	dim := -1
+	for _, set := range [][][]float64{query, train} {
+		for _, d := range set {
+			if len(d) == 0 {
+				return errors.New("Empty descriptor")
+			}
+			if dim == -1 {
+				dim = len(d)
+			} else if len(d) != dim {
+				return errors.New("Descriptors have different dimensions")
+			}
+		}
+	}
+	return nil
+}
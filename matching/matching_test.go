@@ -0,0 +1,25 @@
+package matching
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHammingDistance(t *testing.T) {
+	tests := []struct {
+		a, b []float64
+		want float64
+	}{
+		{[]float64{0, 255}, []float64{255, 255}, 8},
+		{[]float64{5, 3}, []float64{4, 3}, 1},
+		{[]float64{2.6}, []float64{3}, 0},
+		{[]float64{-1, math.NaN()}, []float64{0, 0}, 0},
+		{[]float64{300, math.Inf(1)}, []float64{255, 255}, 0},
+		{[]float64{-300, math.Inf(-1)}, []float64{255, 255}, 16},
+	}
+	for _, test := range tests {
+		if got := HammingDistance(test.a, test.b); got != test.want {
+			t.Errorf("HammingDistance(%v, %v) = %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
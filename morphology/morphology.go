@@ -0,0 +1,78 @@
+package morphology
+
+import (
+	"errors"
+	"image"
+
+	"github.com/alidadar7676/ComputerVision/padding"
+	"github.com/alidadar7676/ComputerVision/parallel"
+)
+
+// Dilate replaces every pixel with the maximum of the size x size square
+// around it. The border is replicated, so it neither grows nor erodes
+// shapes touching the image edge.
+func Dilate(img *image.Gray, size int) (*image.Gray, error) {
+	return rankFilter(img, size, func(a, b uint8) bool { return a > b })
+}
+
+// Erode replaces every pixel with the minimum of the size x size square
+// around it.
+func Erode(img *image.Gray, size int) (*image.Gray, error) {
+	return rankFilter(img, size, func(a, b uint8) bool { return a < b })
+}
+
+// Open erodes and then dilates, which removes bright details smaller than
+// the square.
+func Open(img *image.Gray, size int) (*image.Gray, error) {
+	eroded, err := Erode(img, size)
+	if err != nil {
+		return nil, err
+	}
+	return Dilate(eroded, size)
+}
+
+// Close dilates and then erodes, which fills dark gaps smaller than the
+// square.
+func Close(img *image.Gray, size int) (*image.Gray, error) {
+	dilated, err := Dilate(img, size)
+	if err != nil {
+		return nil, err
+	}
+	return Erode(dilated, size)
+}
+
+// rankFilter keeps the value that wins better over the square. The square is
+// separable, so a horizontal and a vertical pass are enough.
+func rankFilter(img *image.Gray, size int, better func(a, b uint8) bool) (*image.Gray, error) {
+	if size < 1 || size%2 == 0 {
+		return nil, errors.New("Size must be a positive odd number")
+	}
+	padded, err := padding.Padding(img, image.Point{size, size}, padding.BorderReplicate)
+	if err != nil {
+		return nil, err
+	}
+
+	rect := image.Rect(0, 0, img.Rect.Dx(), img.Rect.Dy())
+	horizontal := image.NewGray(image.Rect(0, 0, rect.Dx(), padded.Rect.Dy()))
+	parallel.ForEachPixel(horizontal.Rect, func(x int, y int) {
+		best := padded.Pix[padded.PixOffset(x, y)]
+		for k := 1; k < size; k++ {
+			if v := padded.Pix[padded.PixOffset(x+k, y)]; better(v, best) {
+				best = v
+			}
+		}
+		horizontal.Pix[horizontal.PixOffset(x, y)] = best
+	})
+
+	res := image.NewGray(img.Rect)
+	parallel.ForEachPixel(rect, func(x int, y int) {
+		best := horizontal.Pix[horizontal.PixOffset(x, y)]
+		for k := 1; k < size; k++ {
+			if v := horizontal.Pix[horizontal.PixOffset(x, y+k)]; better(v, best) {
+				best = v
+			}
+		}
+		res.Pix[res.PixOffset(img.Rect.Min.X+x, img.Rect.Min.Y+y)] = best
+	})
+	return res, nil
+}
@@ -0,0 +1,77 @@
+package padding
+
+type BorderMode int
+
+// The border modes follow the usual CV toolkit conventions, shown here for a
+// row abcdefgh padded by three pixels on each side:
+//
+//	BorderReplicate:  aaa|abcdefgh|hhh
+//	BorderConstant:   000|abcdefgh|000
+//	BorderReflect:    cba|abcdefgh|hgf
+//	BorderReflect101: dcb|abcdefgh|gfe
+//	BorderWrap:       fgh|abcdefgh|abc
+//
+// BorderReplicate is the zero value, which is what the package has always done.
+const (
+	BorderReplicate BorderMode = iota
+	BorderConstant
+	BorderReflect
+	BorderReflect101
+	BorderWrap
+)
+
+func (m BorderMode) String() string {
+	switch m {
+	case BorderReplicate:
+		return "replicate"
+	case BorderConstant:
+		return "constant"
+	case BorderReflect:
+		return "reflect"
+	case BorderReflect101:
+		return "reflect101"
+	case BorderWrap:
+		return "wrap"
+	}
+	return "unknown"
+}
+
+// BorderIndex maps the index i onto [0, n) according to mode. It returns -1
+// when the sample lies outside and mode is BorderConstant.
+func BorderIndex(i, n int, mode BorderMode) int {
+	if i >= 0 && i < n {
+		return i
+	}
+	if n <= 0 {
+		return -1
+	}
+	switch mode {
+	case BorderReplicate:
+		if i < 0 {
+			return 0
+		}
+		return n - 1
+	case BorderReflect:
+		i = mod(i, 2*n)
+		if i >= n {
+			i = 2*n - 1 - i
+		}
+		return i
+	case BorderReflect101:
+		if n == 1 {
+			return 0
+		}
+		i = mod(i, 2*n-2)
+		if i >= n {
+			i = 2*n - 2 - i
+		}
+		return i
+	case BorderWrap:
+		return mod(i, n)
+	}
+	return -1
+}
+
+func mod(a, b int) int {
+	return ((a % b) + b) % b
+}
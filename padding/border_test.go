@@ -0,0 +1,119 @@
+package padding
+
+import (
+	"image"
+	"testing"
+
+	"github.com/alidadar7676/ComputerVision/floatImage"
+)
+
+var modes = []BorderMode{BorderReplicate, BorderConstant, BorderReflect, BorderReflect101, BorderWrap}
+
+// pad spells out the row s padded by n samples on each side, with 0 for the
+// constant border.
+func pad(s string, n int, mode BorderMode) string {
+	res := make([]byte, 0, len(s)+2*n)
+	for i := -n; i < len(s)+n; i++ {
+		j := BorderIndex(i, len(s), mode)
+		if j < 0 {
+			res = append(res, '0')
+		} else {
+			res = append(res, s[j])
+		}
+	}
+	return string(res)
+}
+
+// TestBorderIndex checks the modes against the table of the package
+// documentation, and against paddings longer than the row.
+func TestBorderIndex(t *testing.T) {
+	tests := []struct {
+		row  string
+		n    int
+		mode BorderMode
+		want string
+	}{
+		{"abcdefgh", 3, BorderReplicate, "aaaabcdefghhhh"},
+		{"abcdefgh", 3, BorderConstant, "000abcdefgh000"},
+		{"abcdefgh", 3, BorderReflect, "cbaabcdefghhgf"},
+		{"abcdefgh", 3, BorderReflect101, "dcbabcdefghgfe"},
+		{"abcdefgh", 3, BorderWrap, "fghabcdefghabc"},
+		{"abc", 7, BorderReplicate, "aaaaaaaabcccccccc"},
+		{"abc", 7, BorderReflect, "aabccbaabccbaabcc"},
+		{"abc", 7, BorderReflect101, "bcbabcbabcbabcbab"},
+		{"abc", 7, BorderWrap, "cabcabcabcabcabca"},
+		{"a", 2, BorderReflect, "aaaaa"},
+		{"a", 2, BorderReflect101, "aaaaa"},
+		{"a", 2, BorderWrap, "aaaaa"},
+	}
+	for _, test := range tests {
+		if got := pad(test.row, test.n, test.mode); got != test.want {
+			t.Errorf("%s padded by %d with %v: got %s, want %s", test.row, test.n, test.mode, got, test.want)
+		}
+	}
+	for _, mode := range modes {
+		if i := BorderIndex(0, 0, mode); i != -1 {
+			t.Errorf("%v: index %d in an empty row", mode, i)
+		}
+	}
+}
+
+// TestPadding checks that the three padding functions place every sample
+// where BorderIndex says, for kernels larger than the image and images whose
+// bounds do not start at the origin.
+func TestPadding(t *testing.T) {
+	rect := image.Rect(2, -1, 5, 1)
+	gray := image.NewGray(rect)
+	gray16 := image.NewGray16(rect)
+	float := floatImage.NewGray(rect)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			v := 10*(x-rect.Min.X) + (y - rect.Min.Y) + 1
+			gray.Pix[gray.PixOffset(x, y)] = uint8(v)
+			gray16.Pix[gray16.PixOffset(x, y)+1] = uint8(v)
+			float.Pix[float.PixOffset(x, y)] = float64(v)
+		}
+	}
+	kernel := image.Point{X: 9, Y: 6}
+	left, top := kernel.X/2, kernel.Y/2
+	size := rect.Size()
+
+	for _, mode := range modes {
+		paddedGray, err := Padding(gray, kernel, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paddedGray16, err := PaddingGray16(gray16, kernel, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paddedFloat, err := PaddingFloat(float, kernel, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := image.Pt(size.X+kernel.X-1, size.Y+kernel.Y-1)
+		if paddedGray.Rect.Size() != want || paddedGray16.Rect.Size() != want || paddedFloat.Rect.Size() != want {
+			t.Fatalf("%v: padded sizes %v, %v and %v, want %v", mode, paddedGray.Rect.Size(), paddedGray16.Rect.Size(), paddedFloat.Rect.Size(), want)
+		}
+
+		for y := 0; y < want.Y; y++ {
+			for x := 0; x < want.X; x++ {
+				expected := 0
+				srcX, srcY := BorderIndex(x-left, size.X, mode), BorderIndex(y-top, size.Y, mode)
+				if srcX >= 0 && srcY >= 0 {
+					expected = 10*srcX + srcY + 1
+				}
+				if v := int(paddedGray.GrayAt(x, y).Y); v != expected {
+					t.Errorf("%v: Padding (%d, %d) = %d, want %d", mode, x, y, v, expected)
+				}
+				if v := int(paddedGray16.Gray16At(x, y).Y); v != expected {
+					t.Errorf("%v: PaddingGray16 (%d, %d) = %d, want %d", mode, x, y, v, expected)
+				}
+				fx, fy := paddedFloat.Rect.Min.X+x, paddedFloat.Rect.Min.Y+y
+				if v := paddedFloat.FloatAt(fx, fy); v != float64(expected) {
+					t.Errorf("%v: PaddingFloat (%d, %d) = %g, want %d", mode, fx, fy, v, expected)
+				}
+			}
+		}
+	}
+}
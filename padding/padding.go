@@ -3,7 +3,8 @@ package padding
 import (
 	"errors"
 	"image"
-	"image/color"
+
+	"github.com/alidadar7676/ComputerVision/floatImage"
 )
 
 type Paddings struct {
@@ -13,34 +14,82 @@ type Paddings struct {
 	PaddingBottom int
 }
 
-func Padding(img *image.Gray, kernelSize image.Point) (*image.Gray, error) {
+func Padding(img *image.Gray, kernelSize image.Point, mode BorderMode) (*image.Gray, error) {
 	originalSize := img.Bounds().Size()
 	p, error := calculatePaddings(kernelSize, image.Point{kernelSize.X / 2, kernelSize.Y / 2})
 	if error != nil {
 		return nil, error
 	}
+	if img.Rect.Empty() {
+		return nil, errors.New("Empty image")
+	}
 	rect := getRectangleFromPaddings(p, originalSize)
 	padded := image.NewGray(rect)
 
-	for x := p.PaddingLeft; x < originalSize.X+p.PaddingRight; x++ {
-		for y := p.PaddingTop; y < originalSize.Y+p.PaddingBottom; y++ {
-			padded.Set(x, y, img.GrayAt(x-p.PaddingLeft, y-p.PaddingTop))
+	for y := 0; y < rect.Max.Y; y++ {
+		srcY := BorderIndex(y-p.PaddingTop, originalSize.Y, mode)
+		for x := 0; x < rect.Max.X; x++ {
+			srcX := BorderIndex(x-p.PaddingLeft, originalSize.X, mode)
+			if srcX < 0 || srcY < 0 {
+				continue
+			}
+			padded.Pix[padded.PixOffset(x, y)] = img.Pix[img.PixOffset(img.Rect.Min.X+srcX, img.Rect.Min.Y+srcY)]
+		}
+	}
+
+	return padded, nil
+}
+
+// PaddingGray16 is Padding for 16 bit images.
+func PaddingGray16(img *image.Gray16, kernelSize image.Point, mode BorderMode) (*image.Gray16, error) {
+	originalSize := img.Bounds().Size()
+	p, err := calculatePaddings(kernelSize, image.Point{kernelSize.X / 2, kernelSize.Y / 2})
+	if err != nil {
+		return nil, err
+	}
+	if img.Rect.Empty() {
+		return nil, errors.New("Empty image")
+	}
+	rect := getRectangleFromPaddings(p, originalSize)
+	padded := image.NewGray16(rect)
+
+	for y := 0; y < rect.Max.Y; y++ {
+		srcY := BorderIndex(y-p.PaddingTop, originalSize.Y, mode)
+		for x := 0; x < rect.Max.X; x++ {
+			srcX := BorderIndex(x-p.PaddingLeft, originalSize.X, mode)
+			if srcX < 0 || srcY < 0 {
+				continue
+			}
+			i := padded.PixOffset(x, y)
+			j := img.PixOffset(img.Rect.Min.X+srcX, img.Rect.Min.Y+srcY)
+			copy(padded.Pix[i:i+2], img.Pix[j:j+2])
 		}
 	}
 
-	topPaddingReplicate(img, p, func(x int, y int, pixel color.Color) {
-		padded.Set(x, y, pixel)
-	})
-	bottomPaddingReplicate(img, p, func(x int, y int, pixel color.Color) {
-		padded.Set(x, y, pixel)
-	})
-	leftPaddingReplicate(img, padded, p, func(x int, y int, pixel color.Color) {
-		padded.Set(x, y, pixel)
-	})
-	rightPaddingReplicate(img, padded, p, func(x int, y int, pixel color.Color) {
-		padded.Set(x, y, pixel)
-	})
+	return padded, nil
+}
 
+func PaddingFloat(img *floatImage.Gray, kernelSize image.Point, mode BorderMode) (*floatImage.Gray, error) {
+	p, err := calculatePaddings(kernelSize, image.Point{kernelSize.X / 2, kernelSize.Y / 2})
+	if err != nil {
+		return nil, err
+	}
+	if img.Rect.Empty() {
+		return nil, errors.New("Empty image")
+	}
+	size := img.Rect.Size()
+	rect := image.Rect(img.Rect.Min.X-p.PaddingLeft, img.Rect.Min.Y-p.PaddingTop, img.Rect.Max.X+p.PaddingRight, img.Rect.Max.Y+p.PaddingBottom)
+	padded := floatImage.NewGray(rect)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		srcY := BorderIndex(y-img.Rect.Min.Y, size.Y, mode)
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			srcX := BorderIndex(x-img.Rect.Min.X, size.X, mode)
+			if srcX < 0 || srcY < 0 {
+				continue
+			}
+			padded.Pix[padded.PixOffset(x, y)] = img.Pix[img.PixOffset(img.Rect.Min.X+srcX, img.Rect.Min.Y+srcY)]
+		}
+	}
 	return padded, nil
 }
 
@@ -66,43 +115,3 @@ func getRectangleFromPaddings(p Paddings, imgSize image.Point) image.Rectangle {
 	y := p.PaddingTop + p.PaddingBottom + imgSize.Y
 	return image.Rect(0, 0, x, y)
 }
-
-func topPaddingReplicate(img image.Image, p Paddings, setPixel func(int, int, color.Color)) {
-	originalSize := img.Bounds().Size()
-	for x := p.PaddingLeft; x < originalSize.X+p.PaddingLeft; x++ {
-		firstPixel := img.At(x-p.PaddingLeft, p.PaddingTop)
-		for y := 0; y < p.PaddingTop; y++ {
-			setPixel(x, y, firstPixel)
-		}
-	}
-}
-
-func bottomPaddingReplicate(img image.Image, p Paddings, setPixel func(int, int, color.Color)) {
-	originalSize := img.Bounds().Size()
-	for x := p.PaddingLeft; x < originalSize.X+p.PaddingLeft; x++ {
-		lastPixel := img.At(x-p.PaddingLeft, originalSize.Y-1)
-		for y := p.PaddingTop + originalSize.Y; y < originalSize.Y+p.PaddingTop+p.PaddingBottom; y++ {
-			setPixel(x, y, lastPixel)
-		}
-	}
-}
-
-func leftPaddingReplicate(img image.Image, padded image.Image, p Paddings, setPixel func(int, int, color.Color)) {
-	originalSize := img.Bounds().Size()
-	for y := 0; y < originalSize.Y+p.PaddingBottom+p.PaddingTop; y++ {
-		firstPixel := padded.At(p.PaddingLeft, y)
-		for x := 0; x < p.PaddingLeft; x++ {
-			setPixel(x, y, firstPixel)
-		}
-	}
-}
-
-func rightPaddingReplicate(img image.Image, padded image.Image, p Paddings, setPixel func(int, int, color.Color)) {
-	originalSize := img.Bounds().Size()
-	for y := 0; y < originalSize.Y+p.PaddingBottom+p.PaddingTop; y++ {
-		lastPixel := padded.At(originalSize.X+p.PaddingLeft-1, y)
-		for x := originalSize.X + p.PaddingLeft; x < originalSize.X+p.PaddingLeft+p.PaddingRight; x++ {
-			setPixel(x, y, lastPixel)
-		}
-	}
-}
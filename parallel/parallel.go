@@ -0,0 +1,141 @@
+package parallel
+
+import (
+	"image"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+type Partition int
+
+const (
+	RowBands Partition = iota
+	Tiles
+)
+
+// Options configures how work is split and how many goroutines run it. The
+// partition of an image depends only on its rectangle, Partition and TileSize,
+// never on Workers, so results do not change with the number of workers.
+type Options struct {
+	Workers   int
+	Partition Partition
+	TileSize  int
+}
+
+var (
+	defaultMu      sync.RWMutex
+	defaultOptions = Options{Partition: RowBands, TileSize: 64}
+)
+
+func SetDefault(opts Options) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultOptions = opts
+}
+
+func Default() Options {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultOptions
+}
+
+func (o Options) workers() int {
+	if o.Workers <= 0 {
+		return runtime.NumCPU()
+	}
+	return o.Workers
+}
+
+func (o Options) tileSize() int {
+	if o.TileSize <= 0 {
+		return 64
+	}
+	return o.TileSize
+}
+
+// Split partitions rect into row bands of TileSize rows, or into square tiles
+// of TileSize pixels, ordered row-major.
+func (o Options) Split(rect image.Rectangle) []image.Rectangle {
+	size := o.tileSize()
+	parts := make([]image.Rectangle, 0)
+	for y := rect.Min.Y; y < rect.Max.Y; y += size {
+		maxY := minInt(y+size, rect.Max.Y)
+		if o.Partition == RowBands {
+			parts = append(parts, image.Rect(rect.Min.X, y, rect.Max.X, maxY))
+			continue
+		}
+		for x := rect.Min.X; x < rect.Max.X; x += size {
+			parts = append(parts, image.Rect(x, y, minInt(x+size, rect.Max.X), maxY))
+		}
+	}
+	return parts
+}
+
+// Run calls f(i) for every i in [0, n) on the worker pool and waits for all of
+// them to return.
+func (o Options) Run(n int, f func(i int)) {
+	workers := o.workers()
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			f(i)
+		}
+		return
+	}
+
+	var next int64 = -1
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := int(atomic.AddInt64(&next, 1)); i < n; i = int(atomic.AddInt64(&next, 1)) {
+				f(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (o Options) ForEachRect(rect image.Rectangle, f func(r image.Rectangle)) {
+	parts := o.Split(rect)
+	o.Run(len(parts), func(i int) {
+		f(parts[i])
+	})
+}
+
+func (o Options) ForEachPixel(rect image.Rectangle, f func(x int, y int)) {
+	o.ForEachRect(rect, func(r image.Rectangle) {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				f(x, y)
+			}
+		}
+	})
+}
+
+func Split(rect image.Rectangle) []image.Rectangle {
+	return Default().Split(rect)
+}
+
+func Run(n int, f func(i int)) {
+	Default().Run(n, f)
+}
+
+func ForEachRect(rect image.Rectangle, f func(r image.Rectangle)) {
+	Default().ForEachRect(rect, f)
+}
+
+func ForEachPixel(rect image.Rectangle, f func(x int, y int)) {
+	Default().ForEachPixel(rect, f)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
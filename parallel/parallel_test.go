@@ -0,0 +1,85 @@
+package parallel_test
+
+import (
+	"image"
+	"reflect"
+	"testing"
+
+	"github.com/alidadar7676/ComputerVision/convolution"
+	"github.com/alidadar7676/ComputerVision/edgeDetection"
+	"github.com/alidadar7676/ComputerVision/padding"
+	"github.com/alidadar7676/ComputerVision/parallel"
+	"github.com/alidadar7676/ComputerVision/sift"
+	"github.com/alidadar7676/ComputerVision/utils"
+)
+
+func testImage(width, height int, seed int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := (x/9+y/7)%2*150 + (x*seed+y*y)%60
+			img.Pix[img.PixOffset(x, y)] = uint8(v)
+		}
+	}
+	return img
+}
+
+// results runs the parallel algorithms with the default options and returns
+// everything they produce.
+func results(t *testing.T) []interface{} {
+	img := testImage(150, 110, 3)
+	other := testImage(150, 110, 5)
+	sub := img.SubImage(image.Rect(13, 9, 140, 101)).(*image.Gray)
+
+	kernel, _ := convolution.NewKernel(5, 5)
+	for x := 0; x < 5; x++ {
+		for y := 0; y < 5; y++ {
+			kernel.Set(x, y, float64((x*7+y*3)%5)-2)
+		}
+	}
+	convolved, err := convolution.ConvolveGray(sub, kernel, padding.BorderReflect101)
+	if err != nil {
+		t.Fatal(err)
+	}
+	added, err := utils.AddGrayWeighted(img, 0.3, other, 0.7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subtracted := utils.SubtractGrayImages(img, other)
+	sobel, err := edgeDetection.SobelGray(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	canny, err := edgeDetection.CannyGray(img, edgeDetection.DefaultCannyOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := sift.SiftFeatures(img, sift.DefaultSiftOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) == 0 {
+		t.Fatal("no keypoints")
+	}
+	return []interface{}{convolved, added, subtracted, sobel, canny, keys}
+}
+
+// TestWorkers checks that results are bit-identical to the serial path
+// whatever the number of workers.
+func TestWorkers(t *testing.T) {
+	defer parallel.SetDefault(parallel.Default())
+	names := []string{"ConvolveGray", "AddGrayWeighted", "SubtractGrayImages", "SobelGray", "CannyGray", "SiftFeatures"}
+	for _, partition := range []parallel.Partition{parallel.RowBands, parallel.Tiles} {
+		parallel.SetDefault(parallel.Options{Workers: 1, Partition: partition, TileSize: 16})
+		serial := results(t)
+		for _, workers := range []int{2, 3, 8} {
+			parallel.SetDefault(parallel.Options{Workers: workers, Partition: partition, TileSize: 16})
+			res := results(t)
+			for i := range serial {
+				if !reflect.DeepEqual(res[i], serial[i]) {
+					t.Errorf("%s with %d workers and partition %d differs from the serial result", names[i], workers, partition)
+				}
+			}
+		}
+	}
+}
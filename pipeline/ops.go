@@ -0,0 +1,294 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"sort"
+
+	"github.com/alidadar7676/ComputerVision/blurring"
+	"github.com/alidadar7676/ComputerVision/edgeDetection"
+	"github.com/alidadar7676/ComputerVision/morphology"
+	"github.com/alidadar7676/ComputerVision/sift"
+	"github.com/alidadar7676/ComputerVision/utils"
+)
+
+// Kind is the type of the value a step produces.
+type Kind int
+
+const (
+	KindImage Kind = iota
+	KindKeyPoints
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindImage:
+		return "image"
+	case KindKeyPoints:
+		return "keypoints"
+	}
+	return "unknown"
+}
+
+// operation is a configured step: it maps the values of its inputs to its
+// output.
+type operation func(inputs []interface{}) (interface{}, error)
+
+// opSpec describes an operation: the kinds of its inputs and output, and how
+// to build it from the parameters of a step.
+type opSpec struct {
+	inputs []Kind
+	output Kind
+	build  func(p *params) operation
+}
+
+var ops = map[string]opSpec{
+	"grayscale": {
+		inputs: []Kind{KindImage},
+		output: KindImage,
+		build: func(p *params) operation {
+			return func(in []interface{}) (interface{}, error) {
+				if gray, ok := in[0].(*image.Gray); ok {
+					return gray, nil
+				}
+				return utils.GrayScale(in[0].(image.Image)), nil
+			}
+		},
+	},
+	"gaussian_blur": {
+		inputs: []Kind{KindImage},
+		output: KindImage,
+		build: func(p *params) operation {
+			sigma := p.float("sigma", 1)
+			radius := p.float("radius", 0)
+			if sigma <= 0 {
+				p.fail("sigma must be bigger then 0")
+			}
+			if radius < 0 {
+				p.fail("radius must not be negative")
+			}
+			if radius == 0 {
+				radius = math.Ceil(3 * sigma)
+			}
+			return func(in []interface{}) (interface{}, error) {
+				switch img := in[0].(type) {
+				case *image.Gray:
+					return blurring.GaussianBlurGray(img, radius, sigma)
+				case *image.Gray16:
+					return blurring.GaussianBlurGray16(img, radius, sigma)
+				}
+				return blurring.GaussianBlurColor(in[0].(image.Image), radius, sigma)
+			}
+		},
+	},
+	"sobel": {
+		inputs: []Kind{KindImage},
+		output: KindImage,
+		build: func(p *params) operation {
+			return func(in []interface{}) (interface{}, error) {
+				switch img := in[0].(type) {
+				case *image.Gray:
+					return edgeDetection.SobelGray(img)
+				case *image.Gray16:
+					return edgeDetection.SobelGray16(img)
+				}
+				return edgeDetection.SobelColor(in[0].(image.Image))
+			}
+		},
+	},
+	"canny": {
+		inputs: []Kind{KindImage},
+		output: KindImage,
+		build: func(p *params) operation {
+			opts := edgeDetection.DefaultCannyOptions()
+			opts.Sigma = p.float("sigma", opts.Sigma)
+			opts.Low = p.float("low", opts.Low)
+			opts.High = p.float("high", opts.High)
+			opts.L2Gradient = p.bool("l2_gradient", opts.L2Gradient)
+			opts.ApertureSize = p.int("aperture", opts.ApertureSize)
+			opts.MedianSpread = p.float("median_spread", opts.MedianSpread)
+			switch auto := p.string("auto", "manual"); auto {
+			case "manual":
+				opts.Auto = edgeDetection.ThresholdManual
+			case "median":
+				opts.Auto = edgeDetection.ThresholdMedian
+			case "otsu":
+				opts.Auto = edgeDetection.ThresholdOtsu
+			default:
+				p.fail(fmt.Sprintf("unknown threshold strategy %q", auto))
+			}
+			if err := opts.Validate(); err != nil {
+				p.fail(err.Error())
+			}
+			return func(in []interface{}) (interface{}, error) {
+				switch img := in[0].(type) {
+				case *image.Gray:
+					return edgeDetection.CannyGray(img, opts)
+				case *image.Gray16:
+					return edgeDetection.CannyGray16(img, opts)
+				}
+				return edgeDetection.CannyColor(in[0].(image.Image), opts)
+			}
+		},
+	},
+	"sift": {
+		inputs: []Kind{KindImage},
+		output: KindKeyPoints,
+		build: func(p *params) operation {
+			opts := sift.DefaultSiftOptions()
+			opts.Octaves = p.int("octaves", opts.Octaves)
+			opts.Layers = p.int("layers", opts.Layers)
+			opts.Sigma = p.float("sigma", opts.Sigma)
+			opts.ContrastThreshold = p.float("contrast_threshold", opts.ContrastThreshold)
+			opts.EdgeThreshold = p.float("edge_threshold", opts.EdgeThreshold)
+			opts.Upsample = p.bool("upsample", opts.Upsample)
+			opts.MaxFeatures = p.int("max_features", opts.MaxFeatures)
+			opts.RootSIFT = p.bool("root_sift", opts.RootSIFT)
+			if err := opts.Validate(); err != nil {
+				p.fail(err.Error())
+			}
+			return func(in []interface{}) (interface{}, error) {
+				gray, ok := in[0].(*image.Gray)
+				if !ok {
+					gray = utils.GrayScale(in[0].(image.Image))
+				}
+				return sift.SiftFeatures(gray, opts)
+			}
+		},
+	},
+	"threshold": {
+		inputs: []Kind{KindImage},
+		output: KindImage,
+		build: func(p *params) operation {
+			value := p.float("value", 128)
+			otsu := p.bool("otsu", false)
+			invert := p.bool("invert", false)
+			return func(in []interface{}) (interface{}, error) {
+				return utils.Threshold(in[0].(image.Image), value, otsu, invert), nil
+			}
+		},
+	},
+	"dilate": morphologySpec(morphology.Dilate),
+	"erode":  morphologySpec(morphology.Erode),
+	"open":   morphologySpec(morphology.Open),
+	"close":  morphologySpec(morphology.Close),
+}
+
+// Operations returns the names of the available operations.
+func Operations() []string {
+	res := make([]string, 0, len(ops))
+	for name := range ops {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func morphologySpec(f func(img *image.Gray, size int) (*image.Gray, error)) opSpec {
+	return opSpec{
+		inputs: []Kind{KindImage},
+		output: KindImage,
+		build: func(p *params) operation {
+			size := p.int("size", 3)
+			if size < 1 || size%2 == 0 {
+				p.fail("size must be a positive odd number")
+			}
+			return func(in []interface{}) (interface{}, error) {
+				gray, ok := in[0].(*image.Gray)
+				if !ok {
+					gray = utils.GrayScale(in[0].(image.Image))
+				}
+				return f(gray, size)
+			}
+		},
+	}
+}
+
+// params reads the parameters of a step. It records the first problem and
+// which keys were read, so unknown keys can be reported after building.
+type params struct {
+	values map[string]interface{}
+	used   map[string]bool
+	err    error
+}
+
+func newParams(values map[string]interface{}) *params {
+	return &params{values: values, used: make(map[string]bool)}
+}
+
+func (p *params) fail(msg string) {
+	if p.err == nil {
+		p.err = errors.New(msg)
+	}
+}
+
+func (p *params) lookup(key string) (interface{}, bool) {
+	p.used[key] = true
+	v, ok := p.values[key]
+	return v, ok
+}
+
+func (p *params) float(key string, def float64) float64 {
+	v, ok := p.lookup(key)
+	if !ok {
+		return def
+	}
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int:
+		return float64(n)
+	}
+	p.fail(fmt.Sprintf("parameter %s must be a number", key))
+	return def
+}
+
+func (p *params) int(key string, def int) int {
+	v := p.float(key, float64(def))
+	if v != math.Trunc(v) {
+		p.fail(fmt.Sprintf("parameter %s must be an integer", key))
+		return def
+	}
+	return int(v)
+}
+
+func (p *params) bool(key string, def bool) bool {
+	v, ok := p.lookup(key)
+	if !ok {
+		return def
+	}
+	if b, ok := v.(bool); ok {
+		return b
+	}
+	p.fail(fmt.Sprintf("parameter %s must be true or false", key))
+	return def
+}
+
+func (p *params) string(key string, def string) string {
+	v, ok := p.lookup(key)
+	if !ok {
+		return def
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	p.fail(fmt.Sprintf("parameter %s must be a string", key))
+	return def
+}
+
+// unknown returns an error naming the first parameter that was never read.
+func (p *params) unknown() error {
+	keys := make([]string, 0, len(p.values))
+	for key := range p.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !p.used[key] {
+			return fmt.Errorf("unknown parameter %s", key)
+		}
+	}
+	return nil
+}
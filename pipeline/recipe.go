@@ -0,0 +1,172 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Recipe describes a DAG of operations. Inputs names the images bound when
+// the recipe runs. Every step applies Op to the values named by its Inputs,
+// which are recipe inputs or other steps, and is itself named Name. Outputs
+// maps the names of the values to save to file paths, relative to the output
+// directory.
+type Recipe struct {
+	Inputs  []string          `json:"inputs" yaml:"inputs"`
+	Steps   []Step            `json:"steps" yaml:"steps"`
+	Outputs map[string]string `json:"outputs" yaml:"outputs"`
+}
+
+type Step struct {
+	Name   string                 `json:"name" yaml:"name"`
+	Op     string                 `json:"op" yaml:"op"`
+	Inputs []string               `json:"inputs" yaml:"inputs"`
+	Params map[string]interface{} `json:"params" yaml:"params"`
+}
+
+// LoadRecipe reads a recipe from a JSON file, or from YAML when the
+// extension is .yaml or .yml, and validates it.
+func LoadRecipe(path string) (*Recipe, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return ParseYAML(data)
+	}
+	return ParseJSON(data)
+}
+
+func ParseJSON(data []byte) (*Recipe, error) {
+	var r Recipe
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&r); err != nil {
+		return nil, fmt.Errorf("invalid recipe: %v", err)
+	}
+	return &r, r.Validate()
+}
+
+func ParseYAML(data []byte) (*Recipe, error) {
+	var r Recipe
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&r); err != nil {
+		return nil, fmt.Errorf("invalid recipe: %v", err)
+	}
+	return &r, r.Validate()
+}
+
+// Validate checks the whole recipe before anything runs: names are unique,
+// operations and parameters exist, inputs are defined, there are no cycles
+// and every input has the kind its operation expects.
+func (r *Recipe) Validate() error {
+	_, _, err := r.plan()
+	return err
+}
+
+// plan validates the recipe and returns its steps in execution order with
+// their built operations.
+func (r *Recipe) plan() ([]int, []operation, error) {
+	if len(r.Steps) == 0 {
+		return nil, nil, fmt.Errorf("recipe has no steps")
+	}
+	kinds := make(map[string]Kind)
+	for _, name := range r.Inputs {
+		if name == "" {
+			return nil, nil, fmt.Errorf("input without a name")
+		}
+		if _, ok := kinds[name]; ok {
+			return nil, nil, fmt.Errorf("name %q is defined twice", name)
+		}
+		kinds[name] = KindImage
+	}
+
+	steps := make(map[string]int)
+	operations := make([]operation, len(r.Steps))
+	for i, s := range r.Steps {
+		if s.Name == "" {
+			return nil, nil, fmt.Errorf("step %d has no name", i+1)
+		}
+		if _, ok := kinds[s.Name]; ok {
+			return nil, nil, fmt.Errorf("name %q is defined twice", s.Name)
+		}
+		spec, ok := ops[s.Op]
+		if !ok {
+			return nil, nil, fmt.Errorf("step %s: unknown operation %q", s.Name, s.Op)
+		}
+		if len(s.Inputs) != len(spec.inputs) {
+			return nil, nil, fmt.Errorf("step %s: %s takes %d inputs, got %d", s.Name, s.Op, len(spec.inputs), len(s.Inputs))
+		}
+		p := newParams(s.Params)
+		operations[i] = spec.build(p)
+		if p.err == nil {
+			p.err = p.unknown()
+		}
+		if p.err != nil {
+			return nil, nil, fmt.Errorf("step %s: %v", s.Name, p.err)
+		}
+		kinds[s.Name] = spec.output
+		steps[s.Name] = i
+	}
+
+	for _, s := range r.Steps {
+		for k, in := range s.Inputs {
+			kind, ok := kinds[in]
+			if !ok {
+				return nil, nil, fmt.Errorf("step %s: %q is not defined", s.Name, in)
+			}
+			if want := ops[s.Op].inputs[k]; kind != want {
+				return nil, nil, fmt.Errorf("step %s: input %q is %v, %s needs %v", s.Name, in, kind, s.Op, want)
+			}
+		}
+	}
+	for name := range r.Outputs {
+		if _, ok := kinds[name]; !ok {
+			return nil, nil, fmt.Errorf("output %q is not defined", name)
+		}
+	}
+
+	// Kahn's algorithm, always taking the earliest ready step so the order
+	// follows the recipe where the dependencies allow.
+	pending := make([]int, len(r.Steps))
+	dependents := make([][]int, len(r.Steps))
+	for i, s := range r.Steps {
+		for _, in := range s.Inputs {
+			if j, ok := steps[in]; ok {
+				pending[i]++
+				dependents[j] = append(dependents[j], i)
+			}
+		}
+	}
+	order := make([]int, 0, len(r.Steps))
+	done := make([]bool, len(r.Steps))
+	for len(order) < len(r.Steps) {
+		next := -1
+		for i := range r.Steps {
+			if !done[i] && pending[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			for i := range r.Steps {
+				if !done[i] {
+					return nil, nil, fmt.Errorf("step %s is part of a cycle", r.Steps[i].Name)
+				}
+			}
+		}
+		done[next] = true
+		order = append(order, next)
+		for _, d := range dependents[next] {
+			pending[d]--
+		}
+	}
+	return order, operations, nil
+}
@@ -0,0 +1,99 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/alidadar7676/ComputerVision/imageio"
+	"github.com/alidadar7676/ComputerVision/sift"
+)
+
+type Timing struct {
+	Step     string
+	Duration time.Duration
+}
+
+// Result holds every named value of a run, images as image.Image and
+// keypoints as []sift.KeyPoint, and the time each step took in execution
+// order.
+type Result struct {
+	Values  map[string]interface{}
+	Timings []Timing
+}
+
+// Run validates the recipe and executes it on the given inputs, one image
+// for every name in Inputs.
+func (r *Recipe) Run(inputs map[string]image.Image) (*Result, error) {
+	order, operations, err := r.plan()
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Values: make(map[string]interface{})}
+	for _, name := range r.Inputs {
+		img, ok := inputs[name]
+		if !ok {
+			return nil, fmt.Errorf("input %q is missing", name)
+		}
+		res.Values[name] = img
+	}
+
+	for _, i := range order {
+		s := r.Steps[i]
+		args := make([]interface{}, len(s.Inputs))
+		for k, in := range s.Inputs {
+			args[k] = res.Values[in]
+		}
+		start := time.Now()
+		value, err := operations[i](args)
+		if err != nil {
+			return nil, fmt.Errorf("step %s: %v", s.Name, err)
+		}
+		res.Timings = append(res.Timings, Timing{Step: s.Name, Duration: time.Since(start)})
+		res.Values[s.Name] = value
+	}
+	return res, nil
+}
+
+// Save writes the outputs of the recipe below dir. Images are encoded in the
+// format of their extension and keypoints as JSON.
+func (res *Result) Save(r *Recipe, dir string) error {
+	for name, path := range r.Outputs {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		var err error
+		switch value := res.Values[name].(type) {
+		case image.Image:
+			err = imageio.WriteFile(path, value, imageio.DefaultEncodeOptions())
+		case []sift.KeyPoint:
+			err = writeJSON(path, value)
+		default:
+			err = fmt.Errorf("output %q has no value", name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeJSON writes value through an imageio.AtomicFile like the images, so a
+// failed run never leaves a truncated file behind.
+func writeJSON(path string, value interface{}) error {
+	file, err := imageio.CreateAtomic(path)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(file).Encode(value); err != nil {
+		file.Discard()
+		return err
+	}
+	return file.Commit()
+}
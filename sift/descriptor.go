@@ -0,0 +1,151 @@
+package sift
+
+import (
+	"math"
+
+	"github.com/alidadar7676/ComputerVision/floatImage"
+	"github.com/alidadar7676/ComputerVision/parallel"
+)
+
+const (
+	// descriptorScaleFactor is the side of one spatial cell in units of the
+	// keypoint sigma.
+	descriptorScaleFactor = 3.0
+	descriptorClip        = 0.2
+)
+
+func createFeatures(keys []KeyPoint, scaleSpace [][]*floatImage.Gray, opts SiftOptions) {
+	parallel.Run(len(keys), func(i int) {
+		key := &keys[i]
+		key.Feature = computeDescriptor(scaleSpace[key.Octave][key.Scale], key.x, key.y, octaveSigma(*key, opts), key.Orientation, opts.DescriptorWidth, opts.DescriptorBins)
+		if opts.RootSIFT {
+			key.Feature = RootSIFT(key.Feature)
+		}
+	})
+}
+
+// computeDescriptor samples the gradients on a grid rotated to the keypoint
+// orientation and sized to its scale. Every sample is Gaussian weighted and
+// spread over the two nearest spatial cells in each direction and the two
+// nearest orientation bins, then the width*width*bins vector is normalized,
+// clipped at descriptorClip and normalized again.
+func computeDescriptor(img *floatImage.Gray, x, y int, sigma float64, orientation float64, width int, bins int) []float64 {
+	cellSize := descriptorScaleFactor * sigma
+	radius := int(math.Round(cellSize * math.Sqrt2 * float64(width+1) * 0.5))
+	size := img.Rect.Size()
+	if limit := int(math.Hypot(float64(size.X), float64(size.Y))); radius > limit {
+		radius = limit
+	}
+
+	cos, sin := math.Cos(orientation)/cellSize, math.Sin(orientation)/cellSize
+	weightScale := -1 / (0.5 * float64(width*width))
+	binsPerRadian := float64(bins) / (2 * math.Pi)
+	rect := img.Rect.Inset(1)
+
+	// The histogram has one extra cell and bin on each side so that the
+	// interpolation never needs bounds checks; they are folded back below.
+	hist := make([]float64, (width+2)*(width+2)*(bins+2))
+	index := func(row, col, bin int) int {
+		return (row*(width+2)+col)*(bins+2) + bin
+	}
+
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			// Position in the rotated frame, in units of cells.
+			colRot := float64(dx)*cos + float64(dy)*sin
+			rowRot := -float64(dx)*sin + float64(dy)*cos
+			rowBin := rowRot + float64(width)/2 - 0.5
+			colBin := colRot + float64(width)/2 - 0.5
+			px, py := x+dx, y+dy
+			if rowBin <= -1 || rowBin >= float64(width) || colBin <= -1 || colBin >= float64(width) {
+				continue
+			}
+			if px < rect.Min.X || px >= rect.Max.X || py < rect.Min.Y || py >= rect.Max.Y {
+				continue
+			}
+
+			gx := img.FloatAt(px+1, py) - img.FloatAt(px-1, py)
+			gy := img.FloatAt(px, py+1) - img.FloatAt(px, py-1)
+			magnitude := math.Hypot(gx, gy) * math.Exp((colRot*colRot+rowRot*rowRot)*weightScale)
+			oriBin := positiveAngle(math.Atan2(gy, gx)-orientation) * binsPerRadian
+
+			r0, c0, o0 := int(math.Floor(rowBin)), int(math.Floor(colBin)), int(math.Floor(oriBin))
+			dr, dc, do := rowBin-float64(r0), colBin-float64(c0), oriBin-float64(o0)
+			if o0 >= bins {
+				o0 -= bins
+			}
+
+			for ri := 0; ri <= 1; ri++ {
+				wr := magnitude * (1 - dr)
+				if ri == 1 {
+					wr = magnitude * dr
+				}
+				for ci := 0; ci <= 1; ci++ {
+					wc := wr * (1 - dc)
+					if ci == 1 {
+						wc = wr * dc
+					}
+					hist[index(r0+ri+1, c0+ci+1, o0)] += wc * (1 - do)
+					hist[index(r0+ri+1, c0+ci+1, o0+1)] += wc * do
+				}
+			}
+		}
+	}
+
+	feature := make([]float64, width*width*bins)
+	for row := 0; row < width; row++ {
+		for col := 0; col < width; col++ {
+			// Orientation bin bins wraps around to bin 0.
+			hist[index(row+1, col+1, 0)] += hist[index(row+1, col+1, bins)]
+			for bin := 0; bin < bins; bin++ {
+				feature[(row*width+col)*bins+bin] = hist[index(row+1, col+1, bin)]
+			}
+		}
+	}
+
+	normalizeL2(feature)
+	for i, v := range feature {
+		feature[i] = math.Min(v, descriptorClip)
+	}
+	normalizeL2(feature)
+	return feature
+}
+
+// RootSIFT maps a SIFT descriptor to its RootSIFT form: the square root of the
+// L1 normalized vector. Euclidean distances between RootSIFT descriptors
+// correspond to the Hellinger kernel on the original ones.
+func RootSIFT(feature []float64) []float64 {
+	sum := 0.0
+	for _, v := range feature {
+		sum += math.Abs(v)
+	}
+	res := make([]float64, len(feature))
+	if sum == 0 {
+		return res
+	}
+	for i, v := range feature {
+		res[i] = math.Sqrt(math.Abs(v) / sum)
+	}
+	return res
+}
+
+// ToRootSIFT replaces the descriptor of every keypoint by its RootSIFT form.
+func ToRootSIFT(keys []KeyPoint) {
+	for i := range keys {
+		keys[i].Feature = RootSIFT(keys[i].Feature)
+	}
+}
+
+func normalizeL2(v []float64) {
+	sum := 0.0
+	for _, e := range v {
+		sum += e * e
+	}
+	if sum == 0 {
+		return
+	}
+	norm := math.Sqrt(sum)
+	for i := range v {
+		v[i] /= norm
+	}
+}
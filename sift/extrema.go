@@ -0,0 +1,194 @@
+package sift
+
+import (
+	"math"
+
+	"github.com/alidadar7676/ComputerVision/floatImage"
+	"github.com/alidadar7676/ComputerVision/parallel"
+	"github.com/alidadar7676/ComputerVision/utils"
+)
+
+const (
+	// imageBorder keeps extrema far enough from the border for the finite
+	// differences of the refinement.
+	imageBorder        = 5
+	maxInterpolations  = 5
+	maxOffsetMagnitude = float64(1 << 20)
+)
+
+type detector struct {
+	dog               [][]*floatImage.Gray
+	layers            int
+	sigma             float64
+	contrastThreshold float64
+	edgeThreshold     float64
+	// firstScale is the size of a pixel of the first octave in pixels of the
+	// original image.
+	firstScale float64
+}
+
+func (d *detector) extractKeyPoints() []KeyPoint {
+	dirx, diry, dirz := utils.Create3DDirection()
+	// A cheap prefilter before the exact contrast test after refinement.
+	prelimThreshold := 0.5 * d.contrastThreshold / float64(d.layers)
+
+	isExtremum := func(row, col, x, y int) bool {
+		pix := d.dog[row][col].FloatAt(x, y)
+		if math.Abs(pix) <= prelimThreshold {
+			return false
+		}
+		isMax, isMin := pix > 0, pix < 0
+		for i := 0; i < 27 && (isMax || isMin); i++ {
+			if dirx[i] == 0 && diry[i] == 0 && dirz[i] == 0 {
+				continue
+			}
+			neighbour := d.dog[row][col+dirz[i]].FloatAt(x+dirx[i], y+diry[i])
+			isMax = isMax && pix > neighbour
+			isMin = isMin && pix < neighbour
+		}
+		return isMax || isMin
+	}
+
+	candidateKeys := make([]KeyPoint, 0)
+
+	for row := range d.dog {
+		for col := 1; col <= d.layers; col++ {
+			parts := parallel.Split(d.dog[row][col].Rect.Inset(imageBorder))
+			found := make([][]KeyPoint, len(parts))
+
+			parallel.Run(len(parts), func(i int) {
+				r := parts[i]
+				for y := r.Min.Y; y < r.Max.Y; y++ {
+					for x := r.Min.X; x < r.Max.X; x++ {
+						if !isExtremum(row, col, x, y) {
+							continue
+						}
+						if key, ok := d.refine(row, col, x, y); ok {
+							found[i] = append(found[i], key)
+						}
+					}
+				}
+			})
+			for _, keys := range found {
+				candidateKeys = append(candidateKeys, keys...)
+			}
+		}
+	}
+	return candidateKeys
+}
+
+// refine fits a 3D quadratic to the DoG around (x, y, layer) and moves to its
+// extremum until the offset is below half a sample in every dimension. It then
+// rejects low contrast points by the interpolated DoG value and edge
+// responses by the principal curvature ratio.
+func (d *detector) refine(octave, layer, x, y int) (KeyPoint, bool) {
+	var offset, grad [3]float64
+	var hessian [3][3]float64
+
+	i := 0
+	for ; i < maxInterpolations; i++ {
+		grad, hessian = d.derivatives(octave, layer, x, y)
+		var ok bool
+		if offset, ok = solve3(hessian, grad); !ok {
+			return KeyPoint{}, false
+		}
+		if math.Abs(offset[0]) < 0.5 && math.Abs(offset[1]) < 0.5 && math.Abs(offset[2]) < 0.5 {
+			break
+		}
+		for _, o := range offset {
+			if math.Abs(o) > maxOffsetMagnitude {
+				return KeyPoint{}, false
+			}
+		}
+
+		x += int(math.Round(offset[0]))
+		y += int(math.Round(offset[1]))
+		layer += int(math.Round(offset[2]))
+		rect := d.dog[octave][0].Rect.Inset(imageBorder)
+		if layer < 1 || layer > d.layers || x < rect.Min.X || x >= rect.Max.X || y < rect.Min.Y || y >= rect.Max.Y {
+			return KeyPoint{}, false
+		}
+	}
+	if i >= maxInterpolations {
+		return KeyPoint{}, false
+	}
+
+	value := d.dog[octave][layer].FloatAt(x, y)
+	contrast := value + 0.5*(grad[0]*offset[0]+grad[1]*offset[1]+grad[2]*offset[2])
+	if math.Abs(contrast)*float64(d.layers) < d.contrastThreshold {
+		return KeyPoint{}, false
+	}
+
+	trace := hessian[0][0] + hessian[1][1]
+	det := hessian[0][0]*hessian[1][1] - hessian[0][1]*hessian[1][0]
+	if det <= 0 || trace*trace*d.edgeThreshold >= (d.edgeThreshold+1)*(d.edgeThreshold+1)*det {
+		return KeyPoint{}, false
+	}
+
+	octaveScale := math.Pow(2, float64(octave)) * d.firstScale
+	sigma := d.sigma * math.Pow(2, (float64(layer)+offset[2])/float64(d.layers)) * octaveScale
+	return KeyPoint{
+		X:        (float64(x) + offset[0]) * octaveScale,
+		Y:        (float64(y) + offset[1]) * octaveScale,
+		Sigma:    sigma,
+		Size:     2 * sigma,
+		Response: math.Abs(contrast),
+		Octave:   octave,
+		Scale:    layer,
+		x:        x,
+		y:        y,
+		layer:    float64(layer) + offset[2],
+	}, true
+}
+
+// derivatives returns the gradient and the Hessian of the DoG in (x, y,
+// layer) by central differences.
+func (d *detector) derivatives(octave, layer, x, y int) ([3]float64, [3][3]float64) {
+	prev, cur, next := d.dog[octave][layer-1], d.dog[octave][layer], d.dog[octave][layer+1]
+	v := cur.FloatAt(x, y)
+
+	grad := [3]float64{
+		(cur.FloatAt(x+1, y) - cur.FloatAt(x-1, y)) / 2,
+		(cur.FloatAt(x, y+1) - cur.FloatAt(x, y-1)) / 2,
+		(next.FloatAt(x, y) - prev.FloatAt(x, y)) / 2,
+	}
+
+	dxx := cur.FloatAt(x+1, y) + cur.FloatAt(x-1, y) - 2*v
+	dyy := cur.FloatAt(x, y+1) + cur.FloatAt(x, y-1) - 2*v
+	dss := next.FloatAt(x, y) + prev.FloatAt(x, y) - 2*v
+	dxy := (cur.FloatAt(x+1, y+1) - cur.FloatAt(x-1, y+1) - cur.FloatAt(x+1, y-1) + cur.FloatAt(x-1, y-1)) / 4
+	dxs := (next.FloatAt(x+1, y) - next.FloatAt(x-1, y) - prev.FloatAt(x+1, y) + prev.FloatAt(x-1, y)) / 4
+	dys := (next.FloatAt(x, y+1) - next.FloatAt(x, y-1) - prev.FloatAt(x, y+1) + prev.FloatAt(x, y-1)) / 4
+
+	hessian := [3][3]float64{
+		{dxx, dxy, dxs},
+		{dxy, dyy, dys},
+		{dxs, dys, dss},
+	}
+	return grad, hessian
+}
+
+// solve3 returns -m^-1 * v, the Taylor step to the extremum, by Cramer's
+// rule.
+func solve3(m [3][3]float64, v [3]float64) ([3]float64, bool) {
+	det := det3(m)
+	if det == 0 {
+		return [3]float64{}, false
+	}
+
+	var res [3]float64
+	for col := 0; col < 3; col++ {
+		replaced := m
+		for row := 0; row < 3; row++ {
+			replaced[row][col] = v[row]
+		}
+		res[col] = -det3(replaced) / det
+	}
+	return res, true
+}
+
+func det3(m [3][3]float64) float64 {
+	return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+}
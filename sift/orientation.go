@@ -0,0 +1,108 @@
+package sift
+
+import (
+	"math"
+
+	"github.com/alidadar7676/ComputerVision/floatImage"
+	"github.com/alidadar7676/ComputerVision/parallel"
+)
+
+const (
+	orientationBins         = 36
+	orientationPeakRatio    = 0.8
+	orientationSigmaFactor  = 1.5
+	orientationRadiusFactor = 3 * orientationSigmaFactor
+)
+
+// assignOrientations builds a Gaussian weighted histogram of the gradient
+// directions around every keypoint and returns one keypoint per histogram peak
+// that reaches orientationPeakRatio of the highest one. Orientations are in
+// radians in [0, 2*pi), measured in image coordinates (y pointing down).
+func assignOrientations(keys []KeyPoint, scaleSpace [][]*floatImage.Gray, opts SiftOptions) []KeyPoint {
+	oriented := make([][]KeyPoint, len(keys))
+	parallel.Run(len(keys), func(i int) {
+		key := keys[i]
+		hist := orientationHistogram(scaleSpace[key.Octave][key.Scale], key.x, key.y, octaveSigma(key, opts))
+		for _, angle := range histogramPeaks(hist) {
+			key.Orientation = angle
+			key.Angle = angle * 180 / math.Pi
+			oriented[i] = append(oriented[i], key)
+		}
+	})
+
+	result := make([]KeyPoint, 0, len(keys))
+	for _, keys := range oriented {
+		result = append(result, keys...)
+	}
+	return result
+}
+
+// octaveSigma is the blur of the keypoint in the pixel grid of its octave.
+func octaveSigma(key KeyPoint, opts SiftOptions) float64 {
+	return opts.Sigma * math.Pow(2, key.layer/float64(opts.Layers))
+}
+
+func orientationHistogram(img *floatImage.Gray, x, y int, sigma float64) []float64 {
+	raw := make([]float64, orientationBins)
+	radius := int(math.Round(orientationRadiusFactor * sigma))
+	weightSigma := orientationSigmaFactor * sigma
+	rect := img.Rect.Inset(1)
+
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			px, py := x+dx, y+dy
+			if px < rect.Min.X || px >= rect.Max.X || py < rect.Min.Y || py >= rect.Max.Y {
+				continue
+			}
+			gx := img.FloatAt(px+1, py) - img.FloatAt(px-1, py)
+			gy := img.FloatAt(px, py+1) - img.FloatAt(px, py-1)
+			weight := math.Exp(-float64(dx*dx+dy*dy) / (2 * weightSigma * weightSigma))
+			bin := int(math.Round(orientationBins*positiveAngle(math.Atan2(gy, gx))/(2*math.Pi))) % orientationBins
+			raw[bin] += weight * math.Hypot(gx, gy)
+		}
+	}
+
+	// Smooth the circular histogram with a [1 4 6 4 1] / 16 kernel.
+	hist := make([]float64, orientationBins)
+	for i := range hist {
+		at := func(d int) float64 {
+			return raw[(i+d+orientationBins)%orientationBins]
+		}
+		hist[i] = (at(-2)+at(2))/16 + 4*(at(-1)+at(1))/16 + 6*at(0)/16
+	}
+	return hist
+}
+
+// histogramPeaks returns the angles of the local maxima of the circular
+// histogram that reach orientationPeakRatio of the global maximum, refined by
+// fitting a parabola through each peak and its two neighbours.
+func histogramPeaks(hist []float64) []float64 {
+	n := len(hist)
+	max := 0.0
+	for _, h := range hist {
+		max = math.Max(max, h)
+	}
+	if max == 0 {
+		return nil
+	}
+
+	angles := make([]float64, 0)
+	for i, c := range hist {
+		l := hist[(i-1+n)%n]
+		r := hist[(i+1)%n]
+		if c <= l || c <= r || c < orientationPeakRatio*max {
+			continue
+		}
+		bin := float64(i) + 0.5*(l-r)/(l-2*c+r)
+		angles = append(angles, positiveAngle(bin*2*math.Pi/float64(n)))
+	}
+	return angles
+}
+
+func positiveAngle(angle float64) float64 {
+	angle = math.Mod(angle, 2*math.Pi)
+	if angle < 0 {
+		angle += 2 * math.Pi
+	}
+	return angle
+}
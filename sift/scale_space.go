@@ -0,0 +1,131 @@
+package sift
+
+import (
+	"errors"
+	"image"
+	"math"
+
+	"github.com/alidadar7676/ComputerVision/blurring"
+	"github.com/alidadar7676/ComputerVision/floatImage"
+	"github.com/alidadar7676/ComputerVision/utils"
+)
+
+const (
+	// assumedBlur is the blur the camera is assumed to have applied already.
+	assumedBlur = 0.5
+	// minOctaveSize stops the pyramid before octaves get too small to hold an
+	// extremum away from the border.
+	minOctaveSize = 16
+)
+
+// createScaleSpace builds octaves of layers+3 Gaussian images. Inside an
+// octave the blur grows by k = 2^(1/layers) per image, so image layers has
+// twice the blur of image 0 and, halved, becomes the base of the next octave.
+// With Upsample the first octave is built on the image doubled in size.
+func createScaleSpace(img *floatImage.Gray, opts SiftOptions) ([][]*floatImage.Gray, error) {
+	layers := opts.Layers
+	k := math.Pow(2.0, 1.0/float64(layers))
+	sig := make([]float64, layers+3)
+
+	blur := assumedBlur
+	if opts.Upsample {
+		img = upsample(img)
+		blur *= 2
+	}
+
+	// sig[i] is the blur to add to image i-1 to reach Sigma * k^i.
+	sig[0] = math.Sqrt(math.Max(opts.Sigma*opts.Sigma-blur*blur, 0.01))
+	for i := 1; i < layers+3; i++ {
+		prev := opts.Sigma * math.Pow(k, float64(i-1))
+		total := prev * k
+		sig[i] = math.Sqrt(total*total - prev*prev)
+	}
+
+	octave := opts.Octaves
+	if octave == 0 {
+		size := img.Rect.Size()
+		octave = int(math.Round(math.Log2(math.Min(float64(size.X), float64(size.Y))))) - 2
+		if octave < 0 {
+			octave = 0
+		}
+	}
+
+	scaleSpace := make([][]*floatImage.Gray, 0, octave)
+	for row := 0; row < octave; row++ {
+		var base *floatImage.Gray
+		if row == 0 {
+			blurred, err := gaussianBlur(img, sig[0])
+			if err != nil {
+				return nil, err
+			}
+			base = blurred
+		} else {
+			base = halve(scaleSpace[row-1][layers])
+		}
+		if size := base.Rect.Size(); size.X < minOctaveSize || size.Y < minOctaveSize {
+			break
+		}
+
+		images := make([]*floatImage.Gray, layers+3)
+		images[0] = base
+		for col := 1; col < layers+3; col++ {
+			blurred, err := gaussianBlur(images[col-1], sig[col])
+			if err != nil {
+				return nil, err
+			}
+			images[col] = blurred
+		}
+		scaleSpace = append(scaleSpace, images)
+	}
+	if len(scaleSpace) == 0 {
+		return nil, errors.New("Image is too small for SIFT")
+	}
+	return scaleSpace, nil
+}
+
+func createDoG(scaleSpace [][]*floatImage.Gray) ([][]*floatImage.Gray, error) {
+	dog := make([][]*floatImage.Gray, len(scaleSpace))
+	for row := range scaleSpace {
+		dog[row] = make([]*floatImage.Gray, len(scaleSpace[row])-1)
+
+		for col := range dog[row] {
+			sub, err := utils.SubtractFloatImages(scaleSpace[row][col+1], scaleSpace[row][col])
+			if err != nil {
+				return nil, err
+			}
+			dog[row][col] = sub
+		}
+	}
+
+	return dog, nil
+}
+
+func gaussianBlur(img *floatImage.Gray, sigma float64) (*floatImage.Gray, error) {
+	return blurring.GaussianBlur(img, math.Ceil(4*sigma), sigma)
+}
+
+// upsample doubles the size of img by bilinear interpolation, pixel (x, y)
+// of the result samples (x/2, y/2).
+func upsample(img *floatImage.Gray) *floatImage.Gray {
+	size := img.Rect.Size()
+	res := floatImage.NewGray(image.Rect(0, 0, 2*size.X, 2*size.Y))
+	for y := 0; y < 2*size.Y; y++ {
+		for x := 0; x < 2*size.X; x++ {
+			res.Pix[res.PixOffset(x, y)] = img.Bilinear(float64(img.Rect.Min.X)+float64(x)/2, float64(img.Rect.Min.Y)+float64(y)/2)
+		}
+	}
+	return res
+}
+
+// halve keeps every second pixel. The image is already blurred enough that
+// this does not alias.
+func halve(img *floatImage.Gray) *floatImage.Gray {
+	size := img.Rect.Size()
+	res := floatImage.NewGray(image.Rect(0, 0, size.X/2, size.Y/2))
+	for y := 0; y < size.Y/2; y++ {
+		for x := 0; x < size.X/2; x++ {
+			res.Pix[res.PixOffset(x, y)] = img.FloatAt(img.Rect.Min.X+2*x, img.Rect.Min.Y+2*y)
+		}
+	}
+	return res
+}
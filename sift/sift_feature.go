@@ -1,258 +1,165 @@
 package sift
 
 import (
+	"errors"
 	"image"
-	"math"
+	"sort"
 
-	"github.com/alidadar7676/ComputerVision/blurring"
-	"github.com/alidadar7676/ComputerVision/gradient"
+	"github.com/alidadar7676/ComputerVision/floatImage"
 	"github.com/alidadar7676/ComputerVision/utils"
 )
 
+// KeyPoint is a detected feature. X, Y, Sigma and Size are in the pixels of
+// the original image, X and Y including the origin of its bounds, so
+// keypoints can be drawn and matched without knowing the pyramid layout:
+// Sigma is the blur at which the keypoint was found and Size the diameter of
+// its neighbourhood (2 * Sigma). Orientation is in radians and Angle the same
+// direction in degrees, both in [0, 2*pi) measured with y pointing down.
+// Response is the absolute interpolated DoG value. Octave and Scale locate
+// the Gaussian image the keypoint was found in.
 type KeyPoint struct {
-	orientation float64
-	octave      int
-	scale       int
-	x           int
-	y           int
+	X           float64
+	Y           float64
+	Sigma       float64
+	Size        float64
+	Orientation float64
+	Angle       float64
+	Response    float64
+	Octave      int
+	Scale       int
 	Feature     []float64
-}
-
-func SiftFeatures(img *image.Gray, oct int, scale int, tresh float64) []KeyPoint {
-	scaleSpace := createScaleSpace(img, oct, scale)
-	dog := createDoG(oct, scale, scaleSpace)
-	candidate := extractKeyPoints(oct, scale, dog)
-	gradSpec := createGradientSpec(oct, scale, dog)
-	result := filterKeyPoints(candidate, gradSpec, dog, tresh)
-	fetchMaxOrientation(result, gradSpec)
-	createFeatures(result, gradSpec)
 
-	return result
+	// The sample in the pixel grid of the octave and the refined layer.
+	x     int
+	y     int
+	layer float64
 }
 
-func createScaleSpace(img *image.Gray, octave, scale int) [][]*image.Gray {
-	k := math.Pow(2.0, 1.0/float64(scale))
-	sig := make([]float64, scale)
-
-	sig[0] = 1
-	for i := 1; i < scale; i++ {
-		sig[i] = sig[i-1] * k
-	}
-
-	scaleSpace := make([][]*image.Gray, octave)
-	for row := 0; row < octave; row++ {
-		scaleSpace[row] = make([]*image.Gray, scale)
-
-		for col := 0; col < scale; col++ {
-			if row == 0 && col == 0 {
-				scaleSpace[row][col] = img
-			} else if col == 0 {
-				scaleSpace[row][col] = utils.HalveImage(scaleSpace[row-1][col])
-			} else {
-				tmp, err := blurring.GaussianBlurGray(scaleSpace[row][col-1], 5, sig[col])
-				if err != nil {
-					panic("Can not blur the image")
-				}
-				scaleSpace[row][col] = tmp
-			}
-		}
-	}
-	return scaleSpace
+// SiftOptions configures SiftFeatures. Octaves is the number of octaves, 0
+// picks as many as the image size allows, and octaves too small to hold
+// keypoints are always dropped. Layers is the number of scales sampled per
+// octave and Sigma the blur of the first one. ContrastThreshold rejects
+// extrema by their interpolated DoG value (for intensities in [0, 1], divided
+// by Layers) and EdgeThreshold by the ratio of their principal curvatures.
+// Upsample doubles the image before the first octave, which finds about four
+// times more keypoints. MaxFeatures keeps only the strongest keypoints when it
+// is not 0. The descriptor has DescriptorWidth x DescriptorWidth cells of
+// DescriptorBins orientations, turned into RootSIFT when RootSIFT is set.
+type SiftOptions struct {
+	Octaves           int
+	Layers            int
+	Sigma             float64
+	ContrastThreshold float64
+	EdgeThreshold     float64
+	Upsample          bool
+	MaxFeatures       int
+	DescriptorWidth   int
+	DescriptorBins    int
+	RootSIFT          bool
 }
 
-func createDoG(octave, scale int, scaleSpace [][]*image.Gray) [][]*image.Gray {
-	dog := make([][]*image.Gray, octave)
-	for row := 0; row < octave; row++ {
-		dog[row] = make([]*image.Gray, scale-1)
-
-		for col := 0; col < scale-1; col++ {
-			dog[row][col] = utils.SubtractGrayImages(scaleSpace[row][col], scaleSpace[row][col+1])
-		}
+// DefaultSiftOptions returns the parameters of the published algorithm.
+func DefaultSiftOptions() SiftOptions {
+	return SiftOptions{
+		Octaves:           0,
+		Layers:            3,
+		Sigma:             1.6,
+		ContrastThreshold: 0.04,
+		EdgeThreshold:     10,
+		Upsample:          true,
+		MaxFeatures:       0,
+		DescriptorWidth:   4,
+		DescriptorBins:    8,
 	}
-
-	return dog
 }
 
-func extractKeyPoints(octave, scale int, dog [][]*image.Gray) []KeyPoint {
-	dirx, diry, dirz := utils.Create3DDirection()
-
-	localMinimum := func(row, col, x, y int) bool {
-		pix := dog[row][col].GrayAt(x, y).Y
-		for d := 0; d < 27; d++ {
-			if dirx[d] == 0 && diry[d] == 0 && dirz[d] == 0 {
-				continue
-			}
-			if dog[row][col+dirz[d]].GrayAt(x+dirx[d], y+diry[d]).Y <= pix {
-				return false
-			}
-		}
-		return true
+// Validate reports the first parameter out of range, the check SiftFeatures
+// runs before detecting anything.
+func (o SiftOptions) Validate() error {
+	if o.Octaves < 0 {
+		return errors.New("Number of octaves must not be negative")
 	}
-
-	localMaximum := func(row, col, x, y int) bool {
-		pix := dog[row][col].GrayAt(x, y).Y
-		for d := 0; d < 27; d++ {
-			if dirx[d] == 0 && diry[d] == 0 && dirz[d] == 0 {
-				continue
-			}
-			if dog[row][col+dirz[d]].GrayAt(x+dirx[d], y+diry[d]).Y >= pix {
-				return false
-			}
-		}
-		return true
+	if o.Layers < 1 {
+		return errors.New("Number of layers must be at least 1")
 	}
-
-	candidateKeys := make([]KeyPoint, 0)
-
-	for row := 0; row < octave; row++ {
-		for col := 1; col < scale-2; col++ {
-			bound := dog[row][col].Bounds().Size()
-
-			utils.ForEachPixel(bound, func(x, y int) {
-				if x == 0 || y == 0 || x+1 >= dog[row][col].Bounds().Size().X || y+1 >= dog[row][col].Bounds().Size().Y {
-					return
-				}
-				if localMinimum(row, col, x, y) || localMaximum(row, col, x, y) {
-					candidateKeys = append(candidateKeys, KeyPoint{
-						x:      x,
-						y:      y,
-						octave: row,
-						scale:  col,
-					})
-				}
-			})
-		}
+	if o.Sigma <= 0 {
+		return errors.New("Sigma must be bigger then 0")
 	}
-	return candidateKeys
-}
-
-type gradientSpec struct {
-	g     [][]float64
-	theta [][]float64
-	gX    [][]float64
-	gY    [][]float64
-}
-
-func (grad *gradientSpec) normalize() {
-	max := 0.0
-	for _, row := range grad.g {
-		for _, val := range row {
-			max = math.Max(max, val)
-		}
+	if o.ContrastThreshold < 0 {
+		return errors.New("Contrast threshold must not be negative")
 	}
-
-	for row := range grad.g {
-		for col := range grad.g[row] {
-			grad.g[row][col] /= max
-		}
+	if o.EdgeThreshold <= 0 {
+		return errors.New("Edge threshold must be bigger then 0")
 	}
-}
-
-func createGradientSpec(octave, scale int, dog [][]*image.Gray) [][]gradientSpec {
-	gradSpec := make([][]gradientSpec, octave)
-
-	for i := 0; i < octave; i++ {
-		gradSpec[i] = make([]gradientSpec, scale)
+	if o.MaxFeatures < 0 {
+		return errors.New("Max features must not be negative")
 	}
-
-	for row := 0; row < octave; row++ {
-		for col := 1; col < scale-2; col++ {
-			gs := &gradSpec[row][col]
-
-			if gX, err := gradient.Horizontal(dog[row][col]); err != nil {
-				panic("Cannot create gX")
-			} else if gY, err := gradient.Vertical(dog[row][col]); err != nil {
-				panic("Cannot create gX")
-			} else {
-				gs.g, gs.theta = gradient.GradientAndOrientation(dog[row][col].Bounds().Size(), gX, gY)
-				gs.normalize()
-			}
-		}
+	if o.DescriptorWidth < 1 || o.DescriptorBins < 1 {
+		return errors.New("Descriptor width and bins must be at least 1")
 	}
-
-	return gradSpec
+	return nil
 }
 
-func filterKeyPoints(candidate []KeyPoint, gradSpec [][]gradientSpec, dog [][]*image.Gray, tresh float64) []KeyPoint {
-	result := make([]KeyPoint, 0)
-
-	for _, can := range candidate {
-		bound := dog[can.octave][can.scale].Bounds()
-
-		if can.x < 8 || can.x > bound.Size().X-10 || can.y < 8 || can.y > bound.Size().Y-10 {
-			continue
-		}
-
-		if gradSpec[can.octave][can.scale].g[can.x][can.y] > tresh {
-			result = append(result, can)
-		}
+func SiftFeatures(img *image.Gray, opts SiftOptions) ([]KeyPoint, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
 	}
-	return result
-}
-
-func fetchMaxOrientation(keys []KeyPoint, gradSpec [][]gradientSpec) {
-	for ind, key := range keys {
-		keys[ind].orientation = getBiggestOrientation(gradSpec[key.octave][key.scale].theta, key.x, key.y)
+	if img.Rect.Empty() {
+		return nil, errors.New("Empty image")
 	}
-}
-
-func getBiggestOrientation(theta [][]float64, x, y int) float64 {
-	max := -1000.0
-	for row := -8; row < 8; row++ {
-		for col := -8; col < 8; col++ {
-			max = math.Max(max, theta[x+row][y+col])
-		}
+	scaleSpace, err := createScaleSpace(baseImage(img), opts)
+	if err != nil {
+		return nil, err
 	}
-	return max
-}
-
-func createFeatures(keys []KeyPoint, gradSpec [][]gradientSpec) {
-	for ind, key := range keys {
-		gs := &gradSpec[key.octave][key.scale]
-
-		for row := -8; row < 8; row += 4 {
-			for col := -8; col < 8; col += 4 {
-				vector8 := createSub4x4Features(gs, key.x+row, key.y+col, key.orientation)
-				keys[ind].Feature = append(keys[ind].Feature, vector8...)
-			}
-		}
+	dog, err := createDoG(scaleSpace)
+	if err != nil {
+		return nil, err
 	}
-}
 
-func createSub4x4Features(gs *gradientSpec, x, y int, orien float64) []float64 {
-	vector := make([]float64, 8)
-	for row := 0; row < 4; row++ {
-		for col := 0; col < 4; col++ {
-			index := convertAngle(gs.theta[x+row][y+col] - orien)
-			vector[index] += gs.g[x][y]
-		}
+	d := detector{
+		dog:               dog,
+		layers:            opts.Layers,
+		sigma:             opts.Sigma,
+		contrastThreshold: opts.ContrastThreshold,
+		edgeThreshold:     opts.EdgeThreshold,
+		firstScale:        1,
+	}
+	if opts.Upsample {
+		d.firstScale = 0.5
+	}
+	candidate := d.extractKeyPoints()
+	result := retainStrongest(assignOrientations(candidate, scaleSpace, opts), opts.MaxFeatures)
+	createFeatures(result, scaleSpace, opts)
+	for i := range result {
+		result[i].X += float64(img.Rect.Min.X)
+		result[i].Y += float64(img.Rect.Min.Y)
 	}
-	return vector
+
+	return result, nil
 }
 
-func convertAngle(ang float64) int {
-	angle := 180.0 * ang / math.Pi
-	if angle < 0 {
-		angle += 360
+// retainStrongest keeps the max keypoints with the highest response. Ties
+// keep their detection order, so the result is deterministic.
+func retainStrongest(keys []KeyPoint, max int) []KeyPoint {
+	if max == 0 || len(keys) <= max {
+		return keys
 	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].Response > keys[j].Response
+	})
+	return keys[:max]
+}
 
-	switch {
-	case utils.IsBetween(angle, 0, 45):
-		return 0
-	case utils.IsBetween(angle, 45, 90):
-		return 1
-	case utils.IsBetween(angle, 90, 135):
-		return 2
-	case utils.IsBetween(angle, 135, 180):
-		return 3
-	case utils.IsBetween(angle, 180, 225):
-		return 4
-	case utils.IsBetween(angle, 225, 270):
-		return 5
-	case utils.IsBetween(angle, 270, 315):
-		return 6
-	case utils.IsBetween(angle, 315, 360):
-		return 7
+// baseImage converts img to intensities in [0, 1] with its origin at (0, 0),
+// which is what the thresholds of the published algorithm refer to.
+// SiftFeatures adds the origin of img back to the keypoints.
+func baseImage(img *image.Gray) *floatImage.Gray {
+	size := img.Rect.Size()
+	res := floatImage.NewGray(image.Rect(0, 0, size.X, size.Y))
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			res.Pix[res.PixOffset(x, y)] = float64(img.GrayAt(img.Rect.Min.X+x, img.Rect.Min.Y+y).Y) / float64(utils.MaxUint8)
+		}
 	}
-	return 4
+	return res
 }
@@ -0,0 +1,66 @@
+package sift
+
+import (
+	"image"
+	"testing"
+)
+
+// TestSmallImages checks that images too small for a single octave return an
+// error instead of panicking, whatever the number of octaves.
+func TestSmallImages(t *testing.T) {
+	sizes := []image.Rectangle{
+		image.Rect(0, 0, 0, 0),
+		image.Rect(0, 0, 5, 0),
+		image.Rect(0, 0, 1, 1),
+		image.Rect(0, 0, 2, 1),
+		image.Rect(0, 0, 1, 3),
+		image.Rect(3, 4, 7, 6),
+	}
+	for _, rect := range sizes {
+		for _, upsample := range []bool{false, true} {
+			for _, octaves := range []int{0, 4} {
+				opts := DefaultSiftOptions()
+				opts.Upsample = upsample
+				opts.Octaves = octaves
+				img := image.NewGray(rect)
+				for i := range img.Pix {
+					img.Pix[i] = uint8(37 * i)
+				}
+				if _, err := SiftFeatures(img, opts); err == nil {
+					t.Errorf("%v image with upsample %v and %d octaves: no error", rect, upsample, octaves)
+				}
+			}
+		}
+	}
+}
+
+// TestSubImage checks that keypoints of a sub-image are reported in the
+// coordinates of the image it was taken from.
+func TestSubImage(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 96, 96))
+	for y := 0; y < 96; y++ {
+		for x := 0; x < 96; x++ {
+			img.Pix[img.PixOffset(x, y)] = uint8((x*7 + y*13 + (x/8)*(y/8)*29) % 256)
+		}
+	}
+	shifted := image.NewGray(image.Rect(40, 25, 136, 121))
+	copy(shifted.Pix, img.Pix)
+
+	opts := DefaultSiftOptions()
+	want, err := SiftFeatures(img, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := SiftFeatures(shifted, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(want) == 0 || len(got) != len(want) {
+		t.Fatalf("got %d keypoints, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].X != want[i].X+40 || got[i].Y != want[i].Y+25 {
+			t.Errorf("keypoint %d at (%v, %v), want (%v, %v)", i, got[i].X, got[i].Y, want[i].X+40, want[i].Y+25)
+		}
+	}
+}
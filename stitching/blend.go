@@ -0,0 +1,285 @@
+package stitching
+
+import (
+	"image"
+	"math"
+
+	"github.com/alidadar7676/ComputerVision/convolution"
+	"github.com/alidadar7676/ComputerVision/floatImage"
+	"github.com/alidadar7676/ComputerVision/geometry"
+	"github.com/alidadar7676/ComputerVision/padding"
+	"github.com/alidadar7676/ComputerVision/warping"
+)
+
+// minPyramidSize is the smallest side of the coarsest pyramid level.
+const minPyramidSize = 8
+
+func feather(images []image.Image, transforms []geometry.Matrix, canvas image.Rectangle, opts StitchOptions) (*image.NRGBA, error) {
+	sum := floatImage.NewMulti(canvas, 3)
+	total := floatImage.NewGray(canvas)
+	for i, img := range images {
+		color, weight, err := warpImage(img, transforms[i], canvas, opts)
+		if err != nil {
+			return nil, err
+		}
+		for p := range total.Pix {
+			w := weight.Pix[p]
+			if w == 0 {
+				continue
+			}
+			total.Pix[p] += w
+			for c := 0; c < 3; c++ {
+				sum.Pix[3*p+c] += w * color[c].Pix[p]
+			}
+		}
+	}
+
+	for p, w := range total.Pix {
+		if w > 0 {
+			for c := 0; c < 3; c++ {
+				sum.Pix[3*p+c] /= w
+			}
+		}
+	}
+	return compose(sum, total), nil
+}
+
+func multiBand(images []image.Image, transforms []geometry.Matrix, canvas image.Rectangle, opts StitchOptions) (*image.NRGBA, error) {
+	// Every canvas pixel belongs to the image it is most central in.
+	weights := make([]*floatImage.Gray, len(images))
+	for i, img := range images {
+		w, err := warping.WarpPerspective(borderWeight(img.Bounds()), transforms[i], warpOptions(canvas, padding.BorderConstant, opts))
+		if err != nil {
+			return nil, err
+		}
+		weights[i] = w
+	}
+	coverage := floatImage.NewGray(canvas)
+	owner := make([]int, len(coverage.Pix))
+	for p := range owner {
+		owner[p] = -1
+		best := 0.0
+		for i := range weights {
+			if weights[i].Pix[p] > best {
+				owner[p], best = i, weights[i].Pix[p]
+			}
+		}
+		if owner[p] != -1 {
+			coverage.Pix[p] = 1
+		}
+	}
+
+	levels := opts.Bands
+	for levels > 1 && minInt(canvas.Dx(), canvas.Dy())>>uint(levels-1) < minPyramidSize {
+		levels--
+	}
+
+	var sum [3][]*floatImage.Gray
+	var total []*floatImage.Gray
+	for i, img := range images {
+		color, _, err := warpImage(img, transforms[i], canvas, opts)
+		if err != nil {
+			return nil, err
+		}
+		mask := floatImage.NewGray(canvas)
+		for p := range mask.Pix {
+			if owner[p] == i {
+				mask.Pix[p] = 1
+			}
+		}
+
+		masks, err := gaussianPyramid(mask, levels)
+		if err != nil {
+			return nil, err
+		}
+		if total == nil {
+			total = make([]*floatImage.Gray, levels)
+			for l := range total {
+				total[l] = floatImage.NewGray(masks[l].Rect)
+			}
+			for c := range sum {
+				sum[c] = make([]*floatImage.Gray, levels)
+				for l := range sum[c] {
+					sum[c][l] = floatImage.NewGray(masks[l].Rect)
+				}
+			}
+		}
+		for l := range masks {
+			for p, w := range masks[l].Pix {
+				total[l].Pix[p] += w
+			}
+		}
+		for c := 0; c < 3; c++ {
+			bands, err := laplacianPyramid(color[c], levels)
+			if err != nil {
+				return nil, err
+			}
+			for l := range bands {
+				for p, w := range masks[l].Pix {
+					sum[c][l].Pix[p] += w * bands[l].Pix[p]
+				}
+			}
+		}
+	}
+
+	res := floatImage.NewMulti(canvas, 3)
+	for c := 0; c < 3; c++ {
+		for l := range sum[c] {
+			for p, w := range total[l].Pix {
+				if w > 0 {
+					sum[c][l].Pix[p] /= w
+				}
+			}
+		}
+		channel, err := collapse(sum[c])
+		if err != nil {
+			return nil, err
+		}
+		if err := res.SetChannel(c, channel); err != nil {
+			return nil, err
+		}
+	}
+	return compose(res, coverage), nil
+}
+
+// warpImage warps the color channels of img onto the canvas, replicating its
+// border so interpolation and pyramids do not pull in black, and returns its
+// border weight times its alpha as the weight of every canvas pixel.
+func warpImage(img image.Image, transform geometry.Matrix, canvas image.Rectangle, opts StitchOptions) ([3]*floatImage.Gray, *floatImage.Gray, error) {
+	var color [3]*floatImage.Gray
+	src := floatImage.FromColor(img)
+	warped, err := warping.WarpPerspectiveMulti(src, transform, warpOptions(canvas, padding.BorderReplicate, opts))
+	if err != nil {
+		return color, nil, err
+	}
+	for c := range color {
+		color[c] = warped.Channel(c)
+	}
+
+	weight := borderWeight(img.Bounds())
+	alpha := src.Channel(3)
+	for p := range weight.Pix {
+		weight.Pix[p] *= alpha.Pix[p] / 255
+	}
+	weight, err = warping.WarpPerspective(weight, transform, warpOptions(canvas, padding.BorderConstant, opts))
+	if err != nil {
+		return color, nil, err
+	}
+	return color, weight, nil
+}
+
+func warpOptions(canvas image.Rectangle, border padding.BorderMode, opts StitchOptions) warping.WarpOptions {
+	return warping.WarpOptions{Bounds: canvas, Interpolation: opts.Interpolation, Border: border}
+}
+
+// compose builds the panorama from its colors, leaving the pixels without
+// weight transparent.
+func compose(color *floatImage.Multi, weight *floatImage.Gray) *image.NRGBA {
+	res := floatImage.NewMulti(color.Rect, 4)
+	for p, w := range weight.Pix {
+		if w <= 0 {
+			continue
+		}
+		for c := 0; c < 3; c++ {
+			res.Pix[4*p+c] = math.Round(color.Pix[3*p+c])
+		}
+		res.Pix[4*p+3] = 255
+	}
+	return res.ToNRGBA()
+}
+
+// pyramidKernel is the 5-tap binomial filter of Burt and Adelson.
+var pyramidKernel = []float64{1.0 / 16, 4.0 / 16, 6.0 / 16, 4.0 / 16, 1.0 / 16}
+
+func gaussianPyramid(img *floatImage.Gray, levels int) ([]*floatImage.Gray, error) {
+	res := []*floatImage.Gray{img}
+	for len(res) < levels {
+		next, err := reduce(res[len(res)-1])
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, next)
+	}
+	return res, nil
+}
+
+// laplacianPyramid stores in every level the details lost by reducing it;
+// the last level is the coarsest Gaussian level itself.
+func laplacianPyramid(img *floatImage.Gray, levels int) ([]*floatImage.Gray, error) {
+	res, err := gaussianPyramid(img, levels)
+	if err != nil {
+		return nil, err
+	}
+	for l := 0; l < len(res)-1; l++ {
+		up, err := expand(res[l+1], res[l].Rect)
+		if err != nil {
+			return nil, err
+		}
+		for p := range res[l].Pix {
+			res[l].Pix[p] -= up.Pix[p]
+		}
+	}
+	return res, nil
+}
+
+func collapse(pyramid []*floatImage.Gray) (*floatImage.Gray, error) {
+	res := pyramid[len(pyramid)-1]
+	for l := len(pyramid) - 2; l >= 0; l-- {
+		up, err := expand(res, pyramid[l].Rect)
+		if err != nil {
+			return nil, err
+		}
+		for p := range up.Pix {
+			up.Pix[p] += pyramid[l].Pix[p]
+		}
+		res = up
+	}
+	return res, nil
+}
+
+// reduce blurs img and keeps every second pixel. img must start at the
+// origin.
+func reduce(img *floatImage.Gray) (*floatImage.Gray, error) {
+	kernel, err := convolution.NewSeparableKernel(pyramidKernel, pyramidKernel)
+	if err != nil {
+		return nil, err
+	}
+	blurred, err := convolution.Convolve(img, kernel, padding.BorderReflect101)
+	if err != nil {
+		return nil, err
+	}
+	res := floatImage.NewGray(image.Rect(0, 0, (img.Rect.Dx()+1)/2, (img.Rect.Dy()+1)/2))
+	for y := 0; y < res.Rect.Dy(); y++ {
+		for x := 0; x < res.Rect.Dx(); x++ {
+			res.Pix[res.PixOffset(x, y)] = blurred.Pix[blurred.PixOffset(2*x, 2*y)]
+		}
+	}
+	return res, nil
+}
+
+// expand upsamples img to rect by inserting zeros and interpolating them
+// with the pyramid kernel, scaled to keep the brightness.
+func expand(img *floatImage.Gray, rect image.Rectangle) (*floatImage.Gray, error) {
+	scaled := make([]float64, len(pyramidKernel))
+	for i, v := range pyramidKernel {
+		scaled[i] = 2 * v
+	}
+	kernel, err := convolution.NewSeparableKernel(scaled, scaled)
+	if err != nil {
+		return nil, err
+	}
+	sparse := floatImage.NewGray(rect)
+	for y := 0; y < img.Rect.Dy() && 2*y < rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx() && 2*x < rect.Dx(); x++ {
+			sparse.Pix[sparse.PixOffset(2*x, 2*y)] = img.Pix[img.PixOffset(x, y)]
+		}
+	}
+	return convolution.Convolve(sparse, kernel, padding.BorderReflect101)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,270 @@
+package stitching
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/alidadar7676/ComputerVision/floatImage"
+	"github.com/alidadar7676/ComputerVision/geometry"
+	"github.com/alidadar7676/ComputerVision/matching"
+	"github.com/alidadar7676/ComputerVision/sift"
+	"github.com/alidadar7676/ComputerVision/utils"
+	"github.com/alidadar7676/ComputerVision/warping"
+)
+
+type Blend int
+
+// BlendFeather averages the overlapping images weighted by the distance to
+// their borders. BlendMultiBand assigns every canvas pixel to the image it is
+// most central in and blends the seams with a Laplacian pyramid of Bands
+// levels, so low frequencies mix over wide areas and details over narrow ones.
+const (
+	BlendFeather Blend = iota
+	BlendMultiBand
+)
+
+func (b Blend) String() string {
+	switch b {
+	case BlendFeather:
+		return "feather"
+	case BlendMultiBand:
+		return "multiband"
+	}
+	return "unknown"
+}
+
+// StitchOptions configures Stitch. Two images are considered overlapping
+// when the homography between them has at least MinInliers inliers.
+// Reference is the index of the image whose frame the panorama is drawn in;
+// -1 picks the image with the most inliers to the others. MaxPixels bounds
+// the canvas, which grows without limit for wrong homographies.
+type StitchOptions struct {
+	Sift          sift.SiftOptions
+	Match         matching.Options
+	Ransac        geometry.RansacOptions
+	MinInliers    int
+	Reference     int
+	Blend         Blend
+	Bands         int
+	Interpolation warping.Interpolation
+	MaxPixels     int
+}
+
+func DefaultStitchOptions() StitchOptions {
+	siftOptions := sift.DefaultSiftOptions()
+	siftOptions.MaxFeatures = 2000
+	return StitchOptions{
+		Sift:          siftOptions,
+		Match:         matching.DefaultOptions(),
+		Ransac:        geometry.DefaultRansacOptions(),
+		MinInliers:    15,
+		Reference:     -1,
+		Blend:         BlendMultiBand,
+		Bands:         5,
+		Interpolation: warping.InterpolationBilinear,
+		MaxPixels:     50000000,
+	}
+}
+
+func (o StitchOptions) validate(images int) error {
+	if images < 2 {
+		return errors.New("At least two images are needed")
+	}
+	if o.Ransac.Model != geometry.ModelHomography {
+		return errors.New("Panoramas need the homography model")
+	}
+	if o.MinInliers < 4 {
+		return errors.New("Minimum number of inliers must be at least 4")
+	}
+	if o.Reference < -1 || o.Reference >= images {
+		return errors.New("Reference image does not exist")
+	}
+	if o.Blend != BlendFeather && o.Blend != BlendMultiBand {
+		return errors.New("Unknown blend")
+	}
+	if o.Blend == BlendMultiBand && o.Bands < 1 {
+		return errors.New("Number of bands must be at least 1")
+	}
+	if o.MaxPixels < 1 {
+		return errors.New("Max pixels must be at least 1")
+	}
+	return nil
+}
+
+// Stitch registers overlapping images with SIFT matches and RANSAC
+// homographies and blends them into one panorama. Canvas pixels that no image
+// covers are transparent.
+func Stitch(images []image.Image, opts StitchOptions) (*image.NRGBA, error) {
+	if err := opts.validate(len(images)); err != nil {
+		return nil, err
+	}
+
+	transforms, err := Register(images, opts)
+	if err != nil {
+		return nil, err
+	}
+	canvas, err := canvasBounds(images, transforms, opts.MaxPixels)
+	if err != nil {
+		return nil, err
+	}
+	// Move the canvas to the origin so pyramids can halve it.
+	shift := geometry.Translation(float64(-canvas.Min.X), float64(-canvas.Min.Y))
+	for i := range transforms {
+		transforms[i] = shift.Multiply(transforms[i])
+	}
+	canvas = canvas.Sub(canvas.Min)
+
+	switch opts.Blend {
+	case BlendMultiBand:
+		return multiBand(images, transforms, canvas, opts)
+	default:
+		return feather(images, transforms, canvas, opts)
+	}
+}
+
+// Register returns for every image the homography from its pixels to the
+// pixels of the reference image.
+func Register(images []image.Image, opts StitchOptions) ([]geometry.Matrix, error) {
+	if err := opts.validate(len(images)); err != nil {
+		return nil, err
+	}
+
+	keys := make([][]sift.KeyPoint, len(images))
+	for i, img := range images {
+		k, err := sift.SiftFeatures(utils.GrayScale(img), opts.Sift)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = k
+	}
+
+	// pairs[i][j] maps image i to image j and weights[i][j] is its number of
+	// inliers, 0 when the images do not overlap.
+	pairs := make([][]geometry.Matrix, len(images))
+	weights := make([][]int, len(images))
+	for i := range images {
+		pairs[i] = make([]geometry.Matrix, len(images))
+		weights[i] = make([]int, len(images))
+	}
+	for i := range images {
+		for j := i + 1; j < len(images); j++ {
+			h, inliers, err := pairHomography(keys[i], keys[j], opts)
+			if err != nil || inliers < opts.MinInliers {
+				continue
+			}
+			inverse, err := h.Inverse()
+			if err != nil {
+				continue
+			}
+			pairs[i][j], pairs[j][i] = h, inverse
+			weights[i][j], weights[j][i] = inliers, inliers
+		}
+	}
+
+	reference := opts.Reference
+	if reference == -1 {
+		best := -1
+		for i := range weights {
+			sum := 0
+			for _, w := range weights[i] {
+				sum += w
+			}
+			if sum > best {
+				reference, best = i, sum
+			}
+		}
+	}
+
+	// Grow a maximum spanning tree from the reference, so every image is
+	// chained to it through the most reliable homographies.
+	transforms := make([]geometry.Matrix, len(images))
+	added := make([]bool, len(images))
+	transforms[reference] = geometry.Identity()
+	added[reference] = true
+	for n := 1; n < len(images); n++ {
+		from, to, best := -1, -1, 0
+		for u := range images {
+			if !added[u] {
+				continue
+			}
+			for v := range images {
+				if !added[v] && weights[v][u] > best {
+					from, to, best = u, v, weights[v][u]
+				}
+			}
+		}
+		if to == -1 {
+			for v := range images {
+				if !added[v] {
+					return nil, fmt.Errorf("Image %d does not overlap with the others", v)
+				}
+			}
+		}
+		transforms[to] = transforms[from].Multiply(pairs[to][from])
+		added[to] = true
+	}
+
+	return transforms, nil
+}
+
+func pairHomography(first, second []sift.KeyPoint, opts StitchOptions) (geometry.Matrix, int, error) {
+	matches, err := matching.BruteForceMatch(first, second, opts.Match)
+	if err != nil {
+		return geometry.Matrix{}, 0, err
+	}
+	src, dst := geometry.MatchedPoints(first, second, matches)
+	res, err := geometry.Estimate(src, dst, opts.Ransac)
+	if err != nil {
+		return geometry.Matrix{}, 0, err
+	}
+	return res.Transform, res.InlierCount, nil
+}
+
+// canvasBounds is the bounding box of the transformed image corners.
+func canvasBounds(images []image.Image, transforms []geometry.Matrix, maxPixels int) (image.Rectangle, error) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for i, img := range images {
+		b := img.Bounds()
+		corners := []geometry.Point{
+			{X: float64(b.Min.X), Y: float64(b.Min.Y)},
+			{X: float64(b.Max.X - 1), Y: float64(b.Min.Y)},
+			{X: float64(b.Min.X), Y: float64(b.Max.Y - 1)},
+			{X: float64(b.Max.X - 1), Y: float64(b.Max.Y - 1)},
+		}
+		for _, c := range corners {
+			// A corner behind the camera flips the sign of w.
+			w := transforms[i][6]*c.X + transforms[i][7]*c.Y + transforms[i][8]
+			if w <= 0 {
+				return image.Rectangle{}, errors.New("Panorama is too wide for a planar projection")
+			}
+			p := transforms[i].Apply(c)
+			minX, minY = math.Min(minX, p.X), math.Min(minY, p.Y)
+			maxX, maxY = math.Max(maxX, p.X), math.Max(maxY, p.Y)
+		}
+	}
+	width, height := maxX-minX+1, maxY-minY+1
+	if math.IsNaN(width*height) || width*height > float64(maxPixels) {
+		return image.Rectangle{}, errors.New("Panorama is too large, the homographies are probably wrong")
+	}
+	// Rounding keeps the reference image on the pixel grid of the canvas when
+	// the other corners land a fraction of a pixel outside it.
+	return image.Rect(int(math.Round(minX)), int(math.Round(minY)), int(math.Round(maxX))+1, int(math.Round(maxY))+1), nil
+}
+
+// borderWeight is 1 in the middle of img and falls linearly to 0 at its
+// borders.
+func borderWeight(rect image.Rectangle) *floatImage.Gray {
+	res := floatImage.NewGray(rect)
+	w, h := float64(rect.Dx()), float64(rect.Dy())
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			dx := math.Min(float64(x-rect.Min.X)+1, float64(rect.Max.X-x)) / (w / 2)
+			dy := math.Min(float64(y-rect.Min.Y)+1, float64(rect.Max.Y-y)) / (h / 2)
+			res.Pix[res.PixOffset(x, y)] = math.Min(1, dx*dy)
+		}
+	}
+	return res
+}
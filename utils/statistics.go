@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"math"
+	"sort"
+)
+
+func Median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// OtsuThreshold returns the threshold that maximizes the between-class
+// variance of values, computed on a histogram with the given number of bins
+// spanning [min, max] of the values.
+func OtsuThreshold(values []float64, bins int) float64 {
+	if len(values) == 0 || bins <= 0 {
+		return 0
+	}
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	if max == min {
+		return min
+	}
+
+	width := (max - min) / float64(bins)
+	hist := make([]float64, bins)
+	for _, v := range values {
+		bin := int((v - min) / width)
+		if bin >= bins {
+			bin = bins - 1
+		}
+		hist[bin]++
+	}
+
+	total := float64(len(values))
+	sumAll := 0.0
+	for i, h := range hist {
+		sumAll += float64(i) * h
+	}
+
+	best, bestVariance := 0, -1.0
+	weightBack, sumBack := 0.0, 0.0
+	for i, h := range hist {
+		weightBack += h
+		if weightBack == 0 {
+			continue
+		}
+		weightFore := total - weightBack
+		if weightFore == 0 {
+			break
+		}
+		sumBack += float64(i) * h
+		meanBack := sumBack / weightBack
+		meanFore := (sumAll - sumBack) / weightFore
+		variance := weightBack * weightFore * (meanBack - meanFore) * (meanBack - meanFore)
+		if variance > bestVariance {
+			best, bestVariance = i, variance
+		}
+	}
+	return min + float64(best+1)*width
+}
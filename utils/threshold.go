@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"image"
+	"image/draw"
+
+	"github.com/alidadar7676/ComputerVision/floatImage"
+)
+
+// Threshold returns white where the gray value of img is at least value and
+// black elsewhere, or the opposite with invert. 16 bit gray images are
+// compared in their own units, 0-65535, other images after conversion to 8
+// bit gray. With otsu the value is chosen by OtsuThreshold.
+func Threshold(img image.Image, value float64, otsu bool, invert bool) *image.Gray {
+	var values *floatImage.Gray
+	bins := 256
+	switch src := img.(type) {
+	case *image.Gray16:
+		values = floatImage.FromGray16(src)
+		bins = 65536
+	case *image.Gray:
+		values = floatImage.FromGray(src)
+	default:
+		gray := image.NewGray(img.Bounds())
+		draw.Draw(gray, gray.Rect, img, img.Bounds().Min, draw.Src)
+		values = floatImage.FromGray(gray)
+	}
+	if otsu {
+		value = OtsuThreshold(values.Pix, bins)
+	}
+
+	res := image.NewGray(values.Rect)
+	for i, v := range values.Pix {
+		if (v >= value) != invert {
+			res.Pix[i] = MaxUint8
+		}
+	}
+	return res
+}
@@ -6,6 +6,8 @@ import (
 	"image/color"
 	"math"
 
+	"github.com/alidadar7676/ComputerVision/floatImage"
+	"github.com/alidadar7676/ComputerVision/parallel"
 	"github.com/coraldane/resize"
 )
 
@@ -32,11 +34,40 @@ func AddGrayWeighted(img1 *image.Gray, w1 float64, img2 *image.Gray, w2 float64)
 		return nil, errors.New("The size of the two image does not match")
 	}
 	res := image.NewGray(img1.Bounds())
-	ForEachPixel(size1, func(x int, y int) {
-		p1 := img1.GrayAt(x, y)
-		p2 := img2.GrayAt(x, y)
+	parallel.ForEachPixel(image.Rectangle{Max: size1}, func(x int, y int) {
+		p1 := img1.GrayAt(img1.Rect.Min.X+x, img1.Rect.Min.Y+y)
+		p2 := img2.GrayAt(img2.Rect.Min.X+x, img2.Rect.Min.Y+y)
 		sum := Clamp(float64(p1.Y)*w1+float64(p2.Y)*w2, MinUint8, float64(MaxUint8))
-		res.SetGray(x, y, color.Gray{uint8(sum)})
+		res.SetGray(res.Rect.Min.X+x, res.Rect.Min.Y+y, color.Gray{uint8(sum)})
+	})
+	return res, nil
+}
+
+func AddGray16Weighted(img1 *image.Gray16, w1 float64, img2 *image.Gray16, w2 float64) (*image.Gray16, error) {
+	size1 := img1.Bounds().Size()
+	size2 := img2.Bounds().Size()
+	if size1.X != size2.X || size1.Y != size2.Y {
+		return nil, errors.New("The size of the two image does not match")
+	}
+	res := image.NewGray16(img1.Bounds())
+	parallel.ForEachPixel(image.Rectangle{Max: size1}, func(x int, y int) {
+		p1 := img1.Gray16At(img1.Rect.Min.X+x, img1.Rect.Min.Y+y)
+		p2 := img2.Gray16At(img2.Rect.Min.X+x, img2.Rect.Min.Y+y)
+		sum := Clamp(float64(p1.Y)*w1+float64(p2.Y)*w2, MinUint16, float64(MaxUint16))
+		res.SetGray16(res.Rect.Min.X+x, res.Rect.Min.Y+y, color.Gray16{uint16(sum)})
+	})
+	return res, nil
+}
+
+func AddFloatWeighted(img1 *floatImage.Gray, w1 float64, img2 *floatImage.Gray, w2 float64) (*floatImage.Gray, error) {
+	if img1.Rect.Size() != img2.Rect.Size() {
+		return nil, errors.New("The size of the two image does not match")
+	}
+	res := floatImage.NewGray(img1.Rect)
+	parallel.ForEachPixel(image.Rectangle{Max: img1.Rect.Size()}, func(x int, y int) {
+		p1 := img1.FloatAt(img1.Rect.Min.X+x, img1.Rect.Min.Y+y)
+		p2 := img2.FloatAt(img2.Rect.Min.X+x, img2.Rect.Min.Y+y)
+		res.SetFloat(res.Rect.Min.X+x, res.Rect.Min.Y+y, p1*w1+p2*w2)
 	})
 	return res, nil
 }
@@ -44,8 +75,9 @@ func AddGrayWeighted(img1 *image.Gray, w1 float64, img2 *image.Gray, w2 float64)
 func GrayScale(img image.Image) *image.Gray {
 	gray := image.NewGray(img.Bounds())
 	size := img.Bounds().Size()
+	min := img.Bounds().Min
 	ForEachPixel(size, func(x, y int) {
-		gray.Set(x, y, color.GrayModel.Convert(img.At(x, y)))
+		gray.Set(min.X+x, min.Y+y, color.GrayModel.Convert(img.At(min.X+x, min.Y+y)))
 	})
 	return gray
 }
@@ -56,13 +88,17 @@ func SubtractGrayImages(firstImg, secondImg image.Image) *image.Gray {
 	}
 	resImg := image.NewGray(firstImg.Bounds())
 
-	ForEachPixel(firstImg.Bounds().Size(), func(x, y int) {
+	parallel.ForEachPixel(firstImg.Bounds(), func(x, y int) {
 		resImg.Set(x, y, SubtractGrayColor(firstImg.At(x, y), secondImg.At(x, y)))
 	})
 
 	return resImg
 }
 
+func SubtractFloatImages(firstImg, secondImg *floatImage.Gray) (*floatImage.Gray, error) {
+	return AddFloatWeighted(firstImg, 1, secondImg, -1)
+}
+
 func SubtractGrayColor(firstCol, secondCol color.Color) color.Color {
 	_, firstGray, _, _ := firstCol.RGBA()
 	_, secondGray, _, _ := secondCol.RGBA()
@@ -115,12 +151,10 @@ func IsBetween(val float64, lowerBound float64, upperBound float64) bool {
 	return val >= lowerBound && val < upperBound
 }
 
-func CreateGrayImage(mat [][]float64, rect image.Rectangle) *image.Gray {
-	image := image.NewGray(rect)
-	ForEachPixel(rect.Size(), func(x, y int) {
-		pix := Clamp(mat[x][y], MinUint8, float64(MaxUint8))
-		image.Set(x, y, color.Gray{uint8(pix)})
+func CreateGrayImage(mat *floatImage.Gray) *image.Gray {
+	return mat.ToGray()
+}
 
-	})
-	return image
+func CreateGray16Image(mat *floatImage.Gray) *image.Gray16 {
+	return mat.ToGray16()
 }
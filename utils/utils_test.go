@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"image"
+	"testing"
+)
+
+func testGray(rect image.Rectangle, seed int) *image.Gray {
+	img := image.NewGray(rect)
+	for i := range img.Pix {
+		img.Pix[i] = uint8((i*seed + i*i) % 251)
+	}
+	return img
+}
+
+// moved copies img to the same size at the origin.
+func moved(img *image.Gray) *image.Gray {
+	res := image.NewGray(image.Rectangle{Max: img.Rect.Size()})
+	for y := 0; y < res.Rect.Dy(); y++ {
+		copy(res.Pix[y*res.Stride:(y+1)*res.Stride], img.Pix[img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y+y):])
+	}
+	return res
+}
+
+func sameGray(a, b *image.Gray) bool {
+	if a.Rect.Size() != b.Rect.Size() {
+		return false
+	}
+	for y := 0; y < a.Rect.Dy(); y++ {
+		for x := 0; x < a.Rect.Dx(); x++ {
+			if a.GrayAt(a.Rect.Min.X+x, a.Rect.Min.Y+y) != b.GrayAt(b.Rect.Min.X+x, b.Rect.Min.Y+y) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// TestSubImages checks that the per-pixel operations give the same result on
+// sub-images with a non-zero origin as on the same pixels at the origin.
+func TestSubImages(t *testing.T) {
+	parent1 := testGray(image.Rect(0, 0, 40, 30), 3)
+	parent2 := testGray(image.Rect(0, 0, 40, 30), 7)
+	rect := image.Rect(5, 7, 35, 27)
+	sub1 := parent1.SubImage(rect).(*image.Gray)
+	sub2 := parent2.SubImage(rect).(*image.Gray)
+	origin1, origin2 := moved(sub1), moved(sub2)
+
+	added, err := AddGrayWeighted(sub1, 0.5, sub2, 0.25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := AddGrayWeighted(origin1, 0.5, origin2, 0.25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added.Rect != rect || !sameGray(added, want) {
+		t.Error("AddGrayWeighted differs on a sub-image")
+	}
+
+	subtracted := SubtractGrayImages(sub1, sub2)
+	if subtracted.Rect != rect || !sameGray(subtracted, SubtractGrayImages(origin1, origin2)) {
+		t.Error("SubtractGrayImages differs on a sub-image")
+	}
+
+	gray := GrayScale(sub1)
+	if gray.Rect != rect || !sameGray(gray, origin1) {
+		t.Error("GrayScale differs on a sub-image")
+	}
+}
@@ -0,0 +1,99 @@
+package warping
+
+import "math"
+
+type Interpolation int
+
+// InterpolationBicubic uses the Keys kernel with a = -0.5 on 4x4 pixels and
+// InterpolationLanczos a Lanczos window with a = 3 on 6x6 pixels. Both may
+// overshoot near edges; 8 bit results are clamped.
+const (
+	InterpolationNearest Interpolation = iota
+	InterpolationBilinear
+	InterpolationBicubic
+	InterpolationLanczos
+)
+
+func (i Interpolation) String() string {
+	switch i {
+	case InterpolationNearest:
+		return "nearest"
+	case InterpolationBilinear:
+		return "bilinear"
+	case InterpolationBicubic:
+		return "bicubic"
+	case InterpolationLanczos:
+		return "lanczos"
+	}
+	return "unknown"
+}
+
+// radius is the number of pixels the kernel reaches on each side.
+func (i Interpolation) radius() int {
+	switch i {
+	case InterpolationBilinear:
+		return 1
+	case InterpolationBicubic:
+		return 2
+	case InterpolationLanczos:
+		return 3
+	}
+	return 0
+}
+
+// weights fills w with the weights of the pixels first, first+1, ... for a
+// sample at position t and returns first. Integer positions are pixel
+// centers.
+func (i Interpolation) weights(t float64, w []float64) int {
+	if i == InterpolationNearest {
+		w[0] = 1
+		return int(math.Floor(t + 0.5))
+	}
+
+	r := i.radius()
+	base := int(math.Floor(t))
+	first := base - r + 1
+	sum := 0.0
+	for k := range w {
+		d := t - float64(first+k)
+		switch i {
+		case InterpolationBilinear:
+			w[k] = math.Max(0, 1-math.Abs(d))
+		case InterpolationBicubic:
+			w[k] = cubic(d)
+		case InterpolationLanczos:
+			w[k] = lanczos(d, 3)
+		}
+		sum += w[k]
+	}
+	// The Lanczos window does not sum to exactly one.
+	if sum != 0 {
+		for k := range w {
+			w[k] /= sum
+		}
+	}
+	return first
+}
+
+func cubic(x float64) float64 {
+	const a = -0.5
+	x = math.Abs(x)
+	switch {
+	case x < 1:
+		return ((a+2)*x-(a+3))*x*x + 1
+	case x < 2:
+		return ((a*x-5*a)*x+8*a)*x - 4*a
+	}
+	return 0
+}
+
+func lanczos(x float64, a float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	if math.Abs(x) >= a {
+		return 0
+	}
+	px := math.Pi * x
+	return a * math.Sin(px) * math.Sin(px/a) / (px * px)
+}
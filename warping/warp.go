@@ -0,0 +1,190 @@
+package warping
+
+import (
+	"errors"
+	"image"
+	"math"
+
+	"github.com/alidadar7676/ComputerVision/floatImage"
+	"github.com/alidadar7676/ComputerVision/geometry"
+	"github.com/alidadar7676/ComputerVision/padding"
+	"github.com/alidadar7676/ComputerVision/parallel"
+)
+
+// WarpOptions configures the warps. Bounds is the output rectangle in the
+// coordinates the transform maps to; an empty rectangle keeps the bounds of
+// the source. Samples outside the source follow Border, and BorderValue is
+// the value of every channel for padding.BorderConstant.
+type WarpOptions struct {
+	Bounds        image.Rectangle
+	Interpolation Interpolation
+	Border        padding.BorderMode
+	BorderValue   float64
+}
+
+func DefaultWarpOptions() WarpOptions {
+	return WarpOptions{Interpolation: InterpolationBilinear, Border: padding.BorderConstant}
+}
+
+func (o WarpOptions) validate() error {
+	if o.Interpolation < InterpolationNearest || o.Interpolation > InterpolationLanczos {
+		return errors.New("Unknown interpolation")
+	}
+	if o.Border < padding.BorderReplicate || o.Border > padding.BorderWrap {
+		return errors.New("Unknown border mode")
+	}
+	return nil
+}
+
+// WarpPerspective maps img through m: the output pixel at p takes the value
+// of img at the inverse of m applied to p.
+func WarpPerspective(img *floatImage.Gray, m geometry.Matrix, opts WarpOptions) (*floatImage.Gray, error) {
+	res, err := warp(&floatImage.Multi{Pix: img.Pix, Stride: img.Stride, Rect: img.Rect, Channels: 1}, m, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &floatImage.Gray{Pix: res.Pix, Stride: res.Stride, Rect: res.Rect}, nil
+}
+
+// WarpAffine is WarpPerspective for transforms whose last row is 0, 0, 1.
+func WarpAffine(img *floatImage.Gray, m geometry.Matrix, opts WarpOptions) (*floatImage.Gray, error) {
+	if err := checkAffine(m); err != nil {
+		return nil, err
+	}
+	return WarpPerspective(img, m, opts)
+}
+
+func WarpPerspectiveGray(img *image.Gray, m geometry.Matrix, opts WarpOptions) (*image.Gray, error) {
+	res, err := WarpPerspective(floatImage.FromGray(img), m, opts)
+	if err != nil {
+		return nil, err
+	}
+	return res.ToGray(), nil
+}
+
+func WarpAffineGray(img *image.Gray, m geometry.Matrix, opts WarpOptions) (*image.Gray, error) {
+	if err := checkAffine(m); err != nil {
+		return nil, err
+	}
+	return WarpPerspectiveGray(img, m, opts)
+}
+
+// WarpPerspectiveMulti warps every channel of img, including alpha.
+func WarpPerspectiveMulti(img *floatImage.Multi, m geometry.Matrix, opts WarpOptions) (*floatImage.Multi, error) {
+	return warp(img, m, opts)
+}
+
+func WarpAffineMulti(img *floatImage.Multi, m geometry.Matrix, opts WarpOptions) (*floatImage.Multi, error) {
+	if err := checkAffine(m); err != nil {
+		return nil, err
+	}
+	return warp(img, m, opts)
+}
+
+// WarpPerspectiveColor warps the non-premultiplied color and alpha channels
+// of img. With padding.BorderConstant and a BorderValue of 0 the uncovered
+// part of the output is transparent.
+func WarpPerspectiveColor(img image.Image, m geometry.Matrix, opts WarpOptions) (*image.NRGBA, error) {
+	res, err := warp(floatImage.FromColor(img), m, opts)
+	if err != nil {
+		return nil, err
+	}
+	return res.ToNRGBA(), nil
+}
+
+func WarpAffineColor(img image.Image, m geometry.Matrix, opts WarpOptions) (*image.NRGBA, error) {
+	if err := checkAffine(m); err != nil {
+		return nil, err
+	}
+	return WarpPerspectiveColor(img, m, opts)
+}
+
+func checkAffine(m geometry.Matrix) error {
+	if m[6] != 0 || m[7] != 0 || m[8] != 1 {
+		return errors.New("Transform is not affine")
+	}
+	return nil
+}
+
+func warp(img *floatImage.Multi, m geometry.Matrix, opts WarpOptions) (*floatImage.Multi, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	inverse, err := m.Inverse()
+	if err != nil {
+		return nil, err
+	}
+	bounds := opts.Bounds
+	if bounds.Empty() {
+		bounds = img.Rect
+	}
+
+	res := floatImage.NewMulti(bounds, img.Channels)
+	if img.Rect.Empty() {
+		fill(res, opts.BorderValue)
+		return res, nil
+	}
+
+	taps := 2 * opts.Interpolation.radius()
+	if taps == 0 {
+		taps = 1
+	}
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	parallel.ForEachRect(bounds, func(r image.Rectangle) {
+		wx := make([]float64, taps)
+		wy := make([]float64, taps)
+		offsets := make([]int, taps)
+		sum := make([]float64, img.Channels)
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				out := res.PixOffset(x, y)
+				p := inverse.Apply(geometry.Point{X: float64(x), Y: float64(y)})
+				if math.IsNaN(p.X) || math.IsNaN(p.Y) || math.Abs(p.X) > math.MaxInt32 || math.Abs(p.Y) > math.MaxInt32 {
+					for c := range sum {
+						res.Pix[out+c] = opts.BorderValue
+					}
+					continue
+				}
+
+				firstX := opts.Interpolation.weights(p.X-float64(img.Rect.Min.X), wx)
+				firstY := opts.Interpolation.weights(p.Y-float64(img.Rect.Min.Y), wy)
+				for k := range offsets {
+					offsets[k] = padding.BorderIndex(firstX+k, width, opts.Border)
+				}
+				for c := range sum {
+					sum[c] = 0
+				}
+				for j := 0; j < taps; j++ {
+					if wy[j] == 0 {
+						continue
+					}
+					row := padding.BorderIndex(firstY+j, height, opts.Border)
+					for k := 0; k < taps; k++ {
+						if wx[k] == 0 {
+							continue
+						}
+						w := wx[k] * wy[j]
+						if row < 0 || offsets[k] < 0 {
+							for c := range sum {
+								sum[c] += w * opts.BorderValue
+							}
+							continue
+						}
+						in := row*img.Stride + offsets[k]*img.Channels
+						for c := range sum {
+							sum[c] += w * img.Pix[in+c]
+						}
+					}
+				}
+				copy(res.Pix[out:out+img.Channels], sum)
+			}
+		}
+	})
+	return res, nil
+}
+
+func fill(img *floatImage.Multi, value float64) {
+	for i := range img.Pix {
+		img.Pix[i] = value
+	}
+}